@@ -0,0 +1,343 @@
+// Command loadgen measures the realtime fan-out of a running server: it creates a room,
+// connects WebSocket subscribers to it, posts questions and reactions at fixed rates and
+// reports delivery latency, dropped connections and missed events.
+//
+//	go run ./cmd/loadgen -subscribers 500 -questions 10 -reactions 50 -duration 1m
+//
+// Every subscriber comes from the same address: if the server sets WSRS_MAX_CONNECTIONS_PER_IP,
+// raise it (0 disables it) or the connections over the limit are rejected.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/JeanGrijp/ask-me-anything/internal/logger"
+)
+
+type config struct {
+	baseURL      string
+	subscribers  int
+	connectRate  float64
+	questionRate float64
+	reactionRate float64
+	duration     time.Duration
+	grace        time.Duration
+	keepRoom     bool
+}
+
+func main() {
+	var cfg config
+	flag.StringVar(&cfg.baseURL, "url", "http://localhost:8080", "base URL of the server")
+	flag.IntVar(&cfg.subscribers, "subscribers", 100, "WebSocket subscribers connected to the room")
+	flag.Float64Var(&cfg.connectRate, "connect-rate", 200, "new connections per second while ramping up")
+	flag.Float64Var(&cfg.questionRate, "questions", 5, "questions posted per second")
+	flag.Float64Var(&cfg.reactionRate, "reactions", 20, "reactions posted per second")
+	flag.DurationVar(&cfg.duration, "duration", 30*time.Second, "how long to post questions and reactions")
+	flag.DurationVar(&cfg.grace, "grace", 5*time.Second, "how long to wait for the last events after posting stops")
+	flag.BoolVar(&cfg.keepRoom, "keep-room", false, "do not delete the room at the end")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if cfg.subscribers < 1 || cfg.connectRate <= 0 || cfg.questionRate < 0 || cfg.reactionRate < 0 {
+		fmt.Fprintln(os.Stderr, "loadgen: subscribers and connect-rate must be positive, rates cannot be negative")
+		os.Exit(2)
+	}
+
+	report, err := run(ctx, cfg)
+	if err != nil {
+		logger.Default.Fatal(ctx, "load test failed", "error", err)
+	}
+
+	report.print(os.Stdout)
+}
+
+// run executes the load test: ramp up, post for cfg.duration, wait cfg.grace and disconnect
+func run(ctx context.Context, cfg config) (*report, error) {
+	api, err := newAPIClient(cfg.baseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	roomID, err := api.createRoom(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("create room: %w", err)
+	}
+	logger.Default.Info(ctx, "room created", "room_id", roomID)
+
+	if !cfg.keepRoom {
+		defer func() {
+			// O contexto pode já ter sido cancelado por Ctrl+C
+			if err := api.deleteRoom(context.Background(), roomID); err != nil {
+				logger.Default.Warn(ctx, "failed to delete room", "room_id", roomID, "error", err)
+			}
+		}()
+	}
+
+	r := &report{roomID: roomID, target: cfg.subscribers}
+	sent := &sentQuestions{}
+
+	subs, failed := connectSubscribers(ctx, cfg, api.subscribeURL(roomID), sent)
+	r.connectFailures = failed
+	logger.Default.Info(ctx, "subscribers connected", "connected", len(subs), "failed", len(failed))
+
+	if len(subs) > 0 {
+		postCtx, cancel := context.WithTimeout(ctx, cfg.duration)
+		r.posting = post(postCtx, cfg, api, roomID, sent)
+		cancel()
+
+		logger.Default.Info(ctx, "posting finished, waiting for the last events", "grace", cfg.grace.String())
+		select {
+		case <-ctx.Done():
+		case <-time.After(cfg.grace):
+		}
+	}
+
+	for _, sub := range subs {
+		sub.disconnect()
+	}
+	r.subscribers = subs
+
+	return r, nil
+}
+
+// connectSubscribers dials cfg.subscribers connections at cfg.connectRate per second
+func connectSubscribers(ctx context.Context, cfg config, wsURL string, sent *sentQuestions) ([]*subscriber, []error) {
+	var (
+		mu     sync.Mutex
+		subs   []*subscriber
+		failed []error
+		wg     sync.WaitGroup
+	)
+
+	ticker := time.NewTicker(rateInterval(cfg.connectRate))
+	defer ticker.Stop()
+
+	for i := range cfg.subscribers {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return subs, failed
+		case <-ticker.C:
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			sub, err := dialSubscriber(ctx, i, wsURL, sent)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failed = append(failed, err)
+				return
+			}
+			subs = append(subs, sub)
+		}()
+	}
+
+	wg.Wait()
+	return subs, failed
+}
+
+// postingStats counts the requests made while posting
+type postingStats struct {
+	questionsSent   atomic.Int64
+	questionsFailed atomic.Int64
+	reactionsSent   atomic.Int64
+	reactionsFailed atomic.Int64
+}
+
+// post sends questions and reactions at the configured rates until ctx is done. Each request
+// runs in its own goroutine, so a slow server does not lower the offered load.
+func post(ctx context.Context, cfg config, api *apiClient, roomID string, sent *sentQuestions) *postingStats {
+	stats := &postingStats{}
+
+	var (
+		wg         sync.WaitGroup
+		mu         sync.Mutex
+		messageIDs []string
+	)
+
+	loop := func(rate float64, fire func()) {
+		defer wg.Done()
+		if rate == 0 {
+			return
+		}
+
+		ticker := time.NewTicker(rateInterval(rate))
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					fire()
+				}()
+			}
+		}
+	}
+
+	wg.Add(2)
+
+	go loop(cfg.questionRate, func() {
+		n, text := sent.next()
+		messageID, err := api.postQuestion(context.Background(), roomID, text)
+		if err != nil {
+			sent.forget(n)
+			stats.questionsFailed.Add(1)
+			logger.Default.Debug(ctx, "failed to post question", "error", err)
+			return
+		}
+		stats.questionsSent.Add(1)
+
+		mu.Lock()
+		messageIDs = append(messageIDs, messageID)
+		mu.Unlock()
+	})
+
+	go loop(cfg.reactionRate, func() {
+		mu.Lock()
+		if len(messageIDs) == 0 {
+			mu.Unlock()
+			return
+		}
+		messageID := messageIDs[rand.IntN(len(messageIDs))]
+		mu.Unlock()
+
+		if err := api.react(context.Background(), roomID, messageID); err != nil {
+			stats.reactionsFailed.Add(1)
+			logger.Default.Debug(ctx, "failed to react", "error", err)
+			return
+		}
+		stats.reactionsSent.Add(1)
+	})
+
+	wg.Wait()
+	return stats
+}
+
+func rateInterval(perSecond float64) time.Duration {
+	return time.Duration(float64(time.Second) / perSecond)
+}
+
+// apiClient calls the REST API. Reactions require a user session, kept in the cookie jar.
+type apiClient struct {
+	base *url.URL
+	http *http.Client
+}
+
+func newAPIClient(rawURL string) (*apiClient, error) {
+	base, err := url.Parse(strings.TrimSuffix(rawURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	if base.Scheme != "http" && base.Scheme != "https" {
+		return nil, errors.New("url must be http or https")
+	}
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &apiClient{
+		base: base,
+		http: &http.Client{Jar: jar, Timeout: 10 * time.Second},
+	}, nil
+}
+
+// subscribeURL is the WebSocket URL of the room
+func (c *apiClient) subscribeURL(roomID string) string {
+	u := *c.base
+	u.Scheme = "ws"
+	if c.base.Scheme == "https" {
+		u.Scheme = "wss"
+	}
+	u.Path += "/subscribe/" + roomID
+	return u.String()
+}
+
+func (c *apiClient) createRoom(ctx context.Context) (string, error) {
+	var response struct {
+		ID string `json:"id"`
+	}
+	body := map[string]string{
+		"theme": "loadgen " + time.Now().Format(time.RFC3339),
+		// Fora do lobby, para não aparecer para os outros usuários
+		"visibility": "unlisted",
+	}
+	if err := c.do(ctx, http.MethodPost, "/api/rooms/", body, &response); err != nil {
+		return "", err
+	}
+	return response.ID, nil
+}
+
+func (c *apiClient) deleteRoom(ctx context.Context, roomID string) error {
+	return c.do(ctx, http.MethodDelete, "/api/rooms/"+roomID+"/", nil, nil)
+}
+
+func (c *apiClient) postQuestion(ctx context.Context, roomID, message string) (string, error) {
+	var response struct {
+		ID string `json:"id"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/api/rooms/"+roomID+"/messages/", map[string]string{"message": message}, &response); err != nil {
+		return "", err
+	}
+	return response.ID, nil
+}
+
+func (c *apiClient) react(ctx context.Context, roomID, messageID string) error {
+	return c.do(ctx, http.MethodPatch, "/api/rooms/"+roomID+"/messages/"+messageID+"/react", nil, nil)
+}
+
+func (c *apiClient) do(ctx context.Context, method, path string, body, response any) error {
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.base.String()+path, &payload)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("%s %s: %s", method, path, res.Status)
+	}
+	if response == nil {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(response)
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// report gathers the results of a load test
+type report struct {
+	roomID          string
+	target          int
+	connectFailures []error
+	subscribers     []*subscriber
+	posting         *postingStats
+}
+
+func (r *report) print(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	defer tw.Flush()
+
+	fmt.Fprintf(tw, "room\t%s\n", r.roomID)
+	fmt.Fprintf(tw, "subscribers\t%d/%d connected\n", len(r.subscribers), r.target)
+	if len(r.connectFailures) > 0 {
+		fmt.Fprintf(tw, "\t%d failed to connect, first: %v\n", len(r.connectFailures), r.connectFailures[0])
+	}
+
+	if r.posting == nil {
+		return
+	}
+
+	questions := r.posting.questionsSent.Load()
+	fmt.Fprintf(tw, "questions\t%d posted, %d failed\n", questions, r.posting.questionsFailed.Load())
+	fmt.Fprintf(tw, "reactions\t%d posted, %d failed\n", r.posting.reactionsSent.Load(), r.posting.reactionsFailed.Load())
+
+	var (
+		received, delivered, missed int64
+		resyncs, dropped            int
+		closeCodes                  = make(map[int]int)
+		fanOut, endToEnd            []time.Duration
+	)
+	for _, sub := range r.subscribers {
+		received += int64(sub.received)
+		delivered += int64(sub.questions)
+		missed += sub.missed
+		resyncs += sub.resyncs
+		if sub.dropped {
+			dropped++
+			closeCodes[sub.closeCode]++
+		}
+		fanOut = append(fanOut, sub.fanOut...)
+		endToEnd = append(endToEnd, sub.endToEnd...)
+	}
+
+	expected := questions * int64(len(r.subscribers))
+	fmt.Fprintf(tw, "events\t%d received\n", received)
+	fmt.Fprintf(tw, "question deliveries\t%d/%d (%d missing)\n", delivered, expected, max(expected-delivered, 0))
+	fmt.Fprintf(tw, "missed events\t%d sequence gaps, %d resync_required\n", missed, resyncs)
+	fmt.Fprintf(tw, "dropped connections\t%d%s\n", dropped, formatCloseCodes(closeCodes))
+	fmt.Fprintf(tw, "fan-out latency\t%s\n", formatPercentiles(fanOut))
+	fmt.Fprintf(tw, "end-to-end latency\t%s\n", formatPercentiles(endToEnd))
+}
+
+// formatPercentiles summarizes latencies as p50, p90, p99 and max
+func formatPercentiles(latencies []time.Duration) string {
+	if len(latencies) == 0 {
+		return "no samples"
+	}
+
+	slices.Sort(latencies)
+	at := func(p float64) time.Duration {
+		return latencies[int(p*float64(len(latencies)-1))]
+	}
+
+	return fmt.Sprintf("p50 %s  p90 %s  p99 %s  max %s  (%d samples)",
+		round(at(0.50)), round(at(0.90)), round(at(0.99)), round(latencies[len(latencies)-1]), len(latencies))
+}
+
+func round(d time.Duration) time.Duration {
+	if d < time.Millisecond {
+		return d.Round(time.Microsecond)
+	}
+	return d.Round(100 * time.Microsecond)
+}
+
+// formatCloseCodes lists the close codes of the dropped connections, e.g. " (1013: 3)"
+func formatCloseCodes(codes map[int]int) string {
+	if len(codes) == 0 {
+		return ""
+	}
+
+	keys := make([]int, 0, len(codes))
+	for code := range codes {
+		keys = append(keys, code)
+	}
+	slices.Sort(keys)
+
+	s := " ("
+	for i, code := range keys {
+		if i > 0 {
+			s += ", "
+		}
+		s += fmt.Sprintf("%s: %d", closeCodeName(code), codes[code])
+	}
+	return s + ")"
+}
+
+func closeCodeName(code int) string {
+	switch code {
+	case 0:
+		return "no close frame"
+	case websocket.CloseTryAgainLater:
+		return "1013 try again later"
+	case websocket.ClosePolicyViolation:
+		return "1008 policy violation"
+	case websocket.CloseServiceRestart:
+		return "1012 service restart"
+	case websocket.CloseGoingAway:
+		return "1001 going away"
+	}
+	return fmt.Sprint(code)
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/JeanGrijp/ask-me-anything/internal/api"
+	"github.com/gorilla/websocket"
+)
+
+// Prefixo das perguntas enviadas; o número identifica quando a pergunta foi postada
+const questionPrefix = "loadgen question #"
+
+// sentQuestions remembers when each question was posted, to measure end-to-end latency
+type sentQuestions struct {
+	n  atomic.Int64
+	at sync.Map // int64 -> time.Time
+}
+
+// next numbers a new question and records its posting time
+func (s *sentQuestions) next() (int64, string) {
+	n := s.n.Add(1)
+	s.at.Store(n, time.Now())
+	return n, questionPrefix + strconv.FormatInt(n, 10)
+}
+
+// forget drops a question that failed to be posted
+func (s *sentQuestions) forget(n int64) {
+	s.at.Delete(n)
+}
+
+// postedAt returns when the question with this text was posted
+func (s *sentQuestions) postedAt(message string) (time.Time, bool) {
+	raw, ok := strings.CutPrefix(message, questionPrefix)
+	if !ok {
+		return time.Time{}, false
+	}
+	n, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	at, ok := s.at.Load(n)
+	if !ok {
+		return time.Time{}, false
+	}
+	return at.(time.Time), true
+}
+
+// subscriber is one WebSocket connection to the room, speaking the version 2 protocol so
+// every event carries its sequence number and the time it occurred on the server
+type subscriber struct {
+	conn *websocket.Conn
+	sent *sentQuestions
+
+	stopping atomic.Bool
+	done     chan struct{}
+
+	// Escritos só pela goroutine de leitura; lidos depois de done
+	lastSeq   int64
+	received  int
+	questions int
+	missed    int64
+	resyncs   int
+	dropped   bool
+	closeCode int
+	// fanOut mede do evento no servidor até a entrega; endToEnd, da postagem até a entrega
+	fanOut   []time.Duration
+	endToEnd []time.Duration
+}
+
+func dialSubscriber(ctx context.Context, id int, wsURL string, sent *sentQuestions) (*subscriber, error) {
+	dialer := websocket.Dialer{
+		Subprotocols:     []string{api.SubprotocolV2},
+		HandshakeTimeout: 10 * time.Second,
+	}
+
+	conn, res, err := dialer.DialContext(ctx, wsURL, nil)
+	if err != nil {
+		if res != nil {
+			return nil, fmt.Errorf("subscriber %d: %w (%s)", id, err, res.Status)
+		}
+		return nil, fmt.Errorf("subscriber %d: %w", id, err)
+	}
+
+	s := &subscriber{conn: conn, sent: sent, done: make(chan struct{})}
+	go s.read()
+
+	return s, nil
+}
+
+// read consumes events until the connection ends. A connection that ends before disconnect
+// is counted as dropped, with the close code sent by the server.
+func (s *subscriber) read() {
+	defer close(s.done)
+
+	for {
+		_, data, err := s.conn.ReadMessage()
+		if err != nil {
+			if s.stopping.Load() {
+				return
+			}
+
+			s.dropped = true
+			var closeErr *websocket.CloseError
+			if errors.As(err, &closeErr) {
+				s.closeCode = closeErr.Code
+			}
+			return
+		}
+
+		s.handle(data, time.Now())
+	}
+}
+
+func (s *subscriber) handle(data []byte, receivedAt time.Time) {
+	var envelope struct {
+		api.Envelope
+		Payload json.RawMessage `json:"payload"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return
+	}
+
+	s.received++
+	if !envelope.Timestamp.IsZero() {
+		s.fanOut = append(s.fanOut, receivedAt.Sub(envelope.Timestamp))
+	}
+
+	// Eventos pessoais não têm número de sequência
+	if envelope.Seq > 0 {
+		// O primeiro evento só fixa o ponto de partida: o que veio antes da conexão não conta
+		if s.lastSeq > 0 && envelope.Seq > s.lastSeq+1 {
+			s.missed += envelope.Seq - s.lastSeq - 1
+		}
+		s.lastSeq = max(s.lastSeq, envelope.Seq)
+	}
+
+	switch envelope.Type {
+	case api.MessageKindResyncRequired:
+		s.resyncs++
+
+	case api.MessageKindMessageCreated:
+		var created api.MessageMessageCreated
+		if err := json.Unmarshal(envelope.Payload, &created); err != nil {
+			return
+		}
+		if postedAt, ok := s.sent.postedAt(created.Message); ok {
+			s.questions++
+			s.endToEnd = append(s.endToEnd, receivedAt.Sub(postedAt))
+		}
+	}
+}
+
+// disconnect closes the connection normally and waits for the reader to stop
+func (s *subscriber) disconnect() {
+	s.stopping.Store(true)
+
+	message := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "load test finished")
+	_ = s.conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(time.Second))
+
+	select {
+	case <-s.done:
+	case <-time.After(2 * time.Second):
+	}
+	s.conn.Close()
+	<-s.done
+}
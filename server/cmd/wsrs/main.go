@@ -3,15 +3,16 @@ package main
 import (
 	"context"
 	"errors"
+	"flag"
 	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
-	"time"
+	"syscall"
 
 	"github.com/JeanGrijp/ask-me-anything/internal/api"
+	"github.com/JeanGrijp/ask-me-anything/internal/config"
 	"github.com/JeanGrijp/ask-me-anything/internal/logger"
-	"github.com/JeanGrijp/ask-me-anything/internal/store/pgstore"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
 )
@@ -24,16 +25,23 @@ func main() {
 		logger.Default.Info(ctx, "no .env file found or failed to load; relying on environment", "error", err)
 	}
 
+	// Padrões, arquivo YAML, variáveis de ambiente e flags, nessa ordem de precedência
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		logger.Default.Fatal(ctx, "invalid configuration", "error", err)
+	}
+
+	logger.Default = logger.NewZapLogger(cfg.Log)
+
 	logger.Default.Info(ctx, "starting application")
 
-	pool, err := pgxpool.New(ctx, fmt.Sprintf(
-		"user=%s password=%s host=%s port=%s dbname=%s",
-		os.Getenv("WSRS_DATABASE_USER"),
-		os.Getenv("WSRS_DATABASE_PASSWORD"),
-		os.Getenv("WSRS_DATABASE_HOST"),
-		os.Getenv("WSRS_DATABASE_PORT"),
-		os.Getenv("WSRS_DATABASE_NAME"),
-	))
+	// Senhas são mascaradas por String
+	fmt.Fprintf(os.Stderr, "effective configuration:\n%s", cfg)
+
+	pool, err := pgxpool.New(ctx, cfg.Database.ConnString())
 	if err != nil {
 		logger.Default.Fatal(ctx, "failed to create database connection pool", "error", err)
 	}
@@ -47,15 +55,20 @@ func main() {
 
 	logger.Default.Info(ctx, "database connection established")
 
-	handler := api.NewHandler(pgstore.New(pool))
+	// Com mais de uma instância os eventos precisam passar pelo Postgres
+	broadcaster := api.NewInProcessBroadcaster()
+	if cfg.Realtime.Broadcaster == "postgres" {
+		broadcaster = api.NewPostgresBroadcaster(pool)
+	}
+
+	handler := api.NewHandler(pool, broadcaster, cfg)
 
 	server := &http.Server{
-		Addr:    ":8080",
+		Addr:    cfg.Server.Addr,
 		Handler: handler,
 	}
 
-	logger.Default.Info(ctx, "starting HTTP server", "port", 8080)
-	logger.Default.Info(ctx, "visit http://localhost:8080 to access the API")
+	logger.Default.Info(ctx, "starting HTTP server", "addr", cfg.Server.Addr)
 
 	go func() {
 		if err := server.ListenAndServe(); err != nil {
@@ -65,17 +78,29 @@ func main() {
 		}
 	}()
 
+	// SIGTERM é o sinal enviado por Docker e Kubernetes ao parar o container
 	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, os.Interrupt)
-	<-quit
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	sig := <-quit
 
-	logger.Default.Info(ctx, "shutting down application")
+	logger.Default.Info(ctx, "shutting down application", "signal", sig.String())
 
 	// Graceful shutdown com timeout
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Timeouts.Shutdown)
 	defer cancel()
 
+	// Conexões WebSocket são sequestradas e respostas SSE não terminam sozinhas: o servidor
+	// HTTP não as encerra, então os clientes em tempo real são desconectados em paralelo
+	realtimeDone := make(chan error, 1)
+	go func() { realtimeDone <- handler.Shutdown(shutdownCtx) }()
+
 	if err := server.Shutdown(shutdownCtx); err != nil {
-		logger.Default.Fatal(ctx, "server shutdown error", "error", err)
+		logger.Default.Error(ctx, "server shutdown error", "error", err)
 	}
+
+	if err := <-realtimeDone; err != nil {
+		logger.Default.Error(ctx, "realtime clients not drained before the deadline", "error", err)
+	}
+
+	logger.Default.Info(ctx, "application stopped")
 }
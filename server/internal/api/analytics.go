@@ -0,0 +1,300 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/JeanGrijp/ask-me-anything/internal/logger"
+	"github.com/JeanGrijp/ask-me-anything/internal/store/pgstore"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	minAnalyticsBucket = time.Minute
+	maxAnalyticsBucket = 24 * time.Hour
+
+	// Intervalo em que as contagens de assinantes são gravadas no banco
+	subscriberSampleInterval = 15 * time.Second
+	// Amostras de cada instância mais antigas que isso viram uma amostra por sala e hora
+	subscriberSampleRawRetention       = 24 * time.Hour
+	subscriberSampleDownsampleInterval = time.Hour
+)
+
+// RoomAnalyticsResponse summarizes the activity of a room for its host.
+//
+// TotalReactions is the sum of the counts shown on the questions, including counts brought in
+// by an import. The series only sees the reactions recorded with a time, one per session and
+// question, counted when made; their total is TimedReactions, so the two may differ.
+type RoomAnalyticsResponse struct {
+	RoomID                    string            `json:"room_id"`
+	TotalQuestions            int64             `json:"total_questions"`
+	UniqueAskers              int64             `json:"unique_askers"`
+	AnsweredQuestions         int64             `json:"answered_questions"`
+	AnswerRate                float64           `json:"answer_rate"`
+	TotalReactions            int64             `json:"total_reactions"`
+	TimedReactions            int64             `json:"timed_reactions"`
+	MedianTimeToAnswerSeconds *float64          `json:"median_time_to_answer_seconds"`
+	PeakConcurrentSubscribers int32             `json:"peak_concurrent_subscribers"`
+	BucketSeconds             int64             `json:"bucket_seconds,omitempty"`
+	Series                    []AnalyticsBucket `json:"series,omitempty"`
+}
+
+// AnalyticsBucket holds the activity of a room within one time bucket
+type AnalyticsBucket struct {
+	Start           string `json:"start"`
+	Questions       int64  `json:"questions"`
+	Answers         int64  `json:"answers"`
+	Reactions       int64  `json:"reactions"`
+	PeakSubscribers int64  `json:"peak_subscribers"`
+}
+
+// handleGetRoomAnalytics returns the activity numbers of a room (host only).
+// The optional "bucket" query parameter (e.g. 5m, 1h) adds a time-bucketed series.
+func (h apiHandler) handleGetRoomAnalytics(w http.ResponseWriter, r *http.Request) {
+	_, rawRoomID, roomID, ok := h.readRoom(w, r)
+	if !ok {
+		return
+	}
+
+	var bucket time.Duration
+	if rawBucket := r.URL.Query().Get("bucket"); rawBucket != "" {
+		parsed, err := time.ParseDuration(rawBucket)
+		if err != nil || parsed < minAnalyticsBucket || parsed > maxAnalyticsBucket {
+			http.Error(w, "bucket must be a duration between 1m and 24h", http.StatusBadRequest)
+			return
+		}
+		bucket = parsed.Truncate(time.Second)
+	}
+
+	logger.Default.Debug(r.Context(), "computing room analytics", "room_id", rawRoomID, "bucket", bucket.String())
+
+	stats, err := h.q.GetRoomMessageStats(r.Context(), roomID)
+	if err != nil {
+		logger.Default.Error(r.Context(), "failed to get room message stats", "room_id", rawRoomID, "error", err)
+		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		return
+	}
+
+	peak, err := h.q.GetRoomPeakSubscribers(r.Context(), roomID)
+	if err != nil {
+		logger.Default.Error(r.Context(), "failed to get room peak subscribers", "room_id", rawRoomID, "error", err)
+		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		return
+	}
+
+	response := RoomAnalyticsResponse{
+		RoomID:                    rawRoomID,
+		TotalQuestions:            stats.TotalQuestions,
+		UniqueAskers:              stats.UniqueAskers,
+		AnsweredQuestions:         stats.AnsweredQuestions,
+		TotalReactions:            stats.TotalReactions,
+		TimedReactions:            stats.TimedReactions,
+		PeakConcurrentSubscribers: peak,
+	}
+
+	if stats.TotalQuestions > 0 {
+		response.AnswerRate = float64(stats.AnsweredQuestions) / float64(stats.TotalQuestions)
+	}
+
+	// Perguntas respondidas antes do registro de answered_at não entram na mediana
+	if stats.TimedAnswers > 0 {
+		median := stats.MedianSecondsToAnswer
+		response.MedianTimeToAnswerSeconds = &median
+	}
+
+	if bucket > 0 {
+		series, err := h.buildAnalyticsSeries(r.Context(), roomID, int64(bucket.Seconds()))
+		if err != nil {
+			logger.Default.Error(r.Context(), "failed to build analytics series", "room_id", rawRoomID, "error", err)
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		response.BucketSeconds = int64(bucket.Seconds())
+		response.Series = series
+	}
+
+	sendJSON(w, response)
+}
+
+// buildAnalyticsSeries merges the per-bucket counters of a room into a single ordered series
+func (h apiHandler) buildAnalyticsSeries(ctx context.Context, roomID uuid.UUID, bucketSeconds int64) ([]AnalyticsBucket, error) {
+	buckets := make(map[time.Time]*AnalyticsBucket)
+	at := func(start time.Time) *AnalyticsBucket {
+		b, ok := buckets[start]
+		if !ok {
+			b = &AnalyticsBucket{Start: formatTimestamp(start)}
+			buckets[start] = b
+		}
+		return b
+	}
+
+	questions, err := h.q.GetRoomQuestionSeries(ctx, pgstore.GetRoomQuestionSeriesParams{BucketSeconds: bucketSeconds, RoomID: roomID})
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range questions {
+		at(row.Bucket.Time).Questions = row.Total
+	}
+
+	answers, err := h.q.GetRoomAnswerSeries(ctx, pgstore.GetRoomAnswerSeriesParams{BucketSeconds: bucketSeconds, RoomID: roomID})
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range answers {
+		at(row.Bucket.Time).Answers = row.Total
+	}
+
+	reactions, err := h.q.GetRoomReactionSeries(ctx, pgstore.GetRoomReactionSeriesParams{BucketSeconds: bucketSeconds, RoomID: roomID})
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range reactions {
+		at(row.Bucket.Time).Reactions = row.Total
+	}
+
+	subscribers, err := h.q.GetRoomSubscriberSeries(ctx, pgstore.GetRoomSubscriberSeriesParams{BucketSeconds: bucketSeconds, RoomID: roomID})
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range subscribers {
+		at(row.Bucket.Time).PeakSubscribers = row.Total
+	}
+
+	starts := make([]time.Time, 0, len(buckets))
+	for start := range buckets {
+		starts = append(starts, start)
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+
+	series := make([]AnalyticsBucket, 0, len(starts))
+	for _, start := range starts {
+		series = append(series, *buckets[start])
+	}
+
+	return series, nil
+}
+
+// subscriberSampler records the number of WebSocket subscribers per room over time.
+// Connections only update counters in memory; a background loop persists, for each
+// room, the highest count seen since the previous flush. Each instance records its own
+// samples, aligned to the same intervals, and the queries sum the instances of an interval.
+// Samples older than subscriberSampleRawRetention are merged into one per room and hour.
+type subscriberSampler struct {
+	q         *pgstore.Queries
+	instance  string
+	dbTimeout time.Duration
+	mu        sync.Mutex
+	current   map[uuid.UUID]int
+	peaks     map[uuid.UUID]int
+}
+
+// newSubscriberSampler starts the sampling loop, which runs until ctx is canceled.
+// instance identifies the samples of this server instance.
+func newSubscriberSampler(ctx context.Context, q *pgstore.Queries, instance string, dbTimeout time.Duration) *subscriberSampler {
+	s := &subscriberSampler{
+		q:         q,
+		instance:  instance,
+		dbTimeout: dbTimeout,
+		current:   make(map[uuid.UUID]int),
+		peaks:     make(map[uuid.UUID]int),
+	}
+
+	go s.run(ctx, subscriberSampleInterval)
+
+	return s
+}
+
+// observe registers the current number of subscribers of a room
+func (s *subscriberSampler) observe(rawRoomID string, count int) {
+	roomID, err := uuid.Parse(rawRoomID)
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.current[roomID] = count
+	if count > s.peaks[roomID] {
+		s.peaks[roomID] = count
+	}
+}
+
+func (s *subscriberSampler) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	downsample := time.NewTicker(subscriberSampleDownsampleInterval)
+	defer downsample.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// Guarda o que foi observado desde o último intervalo
+			s.flush()
+			return
+		case <-ticker.C:
+			s.flush()
+		case <-downsample.C:
+			s.downsample()
+		}
+	}
+}
+
+// downsample merges the samples older than subscriberSampleRawRetention into one per room
+// and hour, keeping the highest sum of the instances. Every instance runs it; a run that
+// finds the samples already merged does nothing.
+func (s *subscriberSampler) downsample() {
+	ctx, cancel := context.WithTimeout(context.Background(), s.dbTimeout)
+	defer cancel()
+
+	// Só horas completas, para que uma hora não seja agregada em duas vezes
+	before := time.Now().UTC().Add(-subscriberSampleRawRetention).Truncate(time.Hour)
+
+	merged, err := s.q.DownsampleRoomSubscriberSamples(ctx, pgtype.Timestamp{Time: before, Valid: true})
+	if err != nil {
+		logger.Default.Warn(ctx, "failed to downsample subscriber samples", "error", err)
+		return
+	}
+
+	logger.Default.Debug(ctx, "subscriber samples downsampled", "before", before, "hourly_samples", merged)
+}
+
+// flush persists one sample per room that had subscribers during the last interval
+func (s *subscriberSampler) flush() {
+	s.mu.Lock()
+	samples := make(map[uuid.UUID]int, len(s.peaks))
+	for roomID, peak := range s.peaks {
+		samples[roomID] = peak
+	}
+	for roomID, count := range s.current {
+		if count > samples[roomID] {
+			samples[roomID] = count
+		}
+		if count == 0 {
+			delete(s.current, roomID)
+		}
+	}
+	s.peaks = make(map[uuid.UUID]int)
+	s.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.dbTimeout)
+	defer cancel()
+
+	for roomID, count := range samples {
+		err := s.q.InsertRoomSubscriberSample(ctx, pgstore.InsertRoomSubscriberSampleParams{
+			RoomID:          roomID,
+			Instance:        s.instance,
+			SubscriberCount: int32(count),
+			SlotSeconds:     int64(subscriberSampleInterval / time.Second),
+		})
+		if err != nil {
+			logger.Default.Debug(ctx, "failed to record subscriber sample", "room_id", roomID.String(), "error", err)
+		}
+	}
+}
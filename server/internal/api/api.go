@@ -3,54 +3,96 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/JeanGrijp/ask-me-anything/internal/auth"
+	"github.com/JeanGrijp/ask-me-anything/internal/config"
 	"github.com/JeanGrijp/ask-me-anything/internal/logger"
 	custommiddleware "github.com/JeanGrijp/ask-me-anything/internal/middleware"
 	"github.com/JeanGrijp/ask-me-anything/internal/store/pgstore"
 	"github.com/JeanGrijp/ask-me-anything/internal/utils"
+	"github.com/JeanGrijp/ask-me-anything/internal/webhooks"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type apiHandler struct {
 	q              *pgstore.Queries
+	pool           *pgxpool.Pool
 	r              *chi.Mux
 	upgrader       websocket.Upgrader
-	subscribers    map[string]map[*websocket.Conn]context.CancelFunc
-	mu             *sync.Mutex
+	hub            *hub
+	events         *roomEventLog
+	broadcaster    Broadcaster
 	sessionMgr     *auth.SessionManager
 	userSessionMgr *auth.UserSessionManager
+	samples        *subscriberSampler
+	presence       *presenceTracker
+	reactions      *reactionCoalescer
+	lobby          *lobby
+	dispatcher     *webhooks.Dispatcher
+	timeouts       config.Timeouts
 }
 
 func (h apiHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	h.r.ServeHTTP(w, r)
 }
 
-func NewHandler(q *pgstore.Queries) http.Handler {
-	sessionMgr := auth.NewSessionManager()
-	userSessionMgr := auth.NewUserSessionManager(q)
+// NewHandler returns the HTTP handler of the API, configured by cfg: allowed origins,
+// connection limits, session durations and timeouts.
+func NewHandler(pool *pgxpool.Pool, broadcaster Broadcaster, cfg config.Config) *Handler {
+	allowedOrigins := cfg.Server.AllowedOrigins
+
+	q := pgstore.New(pool)
+	sessionMgr := auth.NewSessionManager(cfg.Sessions)
+	userSessionMgr := auth.NewUserSessionManager(q, cfg.Sessions)
+
+	// Encerrado por Handler.Shutdown
+	ctx, stop := context.WithCancel(context.Background())
+
+	// Identifica esta instância nas amostras de assinantes e nas contagens de presença
+	instance := uuid.NewString()
+	samples := newSubscriberSampler(ctx, q, instance, cfg.Timeouts.Database)
 
 	a := apiHandler{
-		q: q,
+		q:    q,
+		pool: pool,
 		upgrader: websocket.Upgrader{
-			CheckOrigin: func(r *http.Request) bool { return true },
+			CheckOrigin:  checkOrigin(allowedOrigins),
+			Subprotocols: supportedSubprotocols,
+			// permessage-deflate, quando o cliente pedir
+			EnableCompression: true,
 			// Configurações básicas para evitar problemas de hijacking
 			ReadBufferSize:  1024,
 			WriteBufferSize: 1024,
 		},
-		subscribers:    make(map[string]map[*websocket.Conn]context.CancelFunc),
-		mu:             &sync.Mutex{},
+		hub:            newHub(samples, cfg.Realtime.Limits),
+		events:         newRoomEventLog(q),
+		broadcaster:    broadcaster,
 		sessionMgr:     sessionMgr,
 		userSessionMgr: userSessionMgr,
+		samples:        samples,
+		dispatcher:     webhooks.NewDispatcher(q, webhooks.NewTargetPolicy(cfg.Webhooks.AllowedPrefixes())),
+		timeouts:       cfg.Timeouts,
 	}
 
+	a.presence = newPresenceTracker(instance, a.deliver, a.publishEphemeral)
+	a.reactions = newReactionCoalescer(a.notifyClients)
+	a.lobby = newLobby(q, a.hub, cfg.Timeouts.Database)
+
+	go a.dispatcher.Run(ctx)
+	go a.lobby.run(ctx)
+	go a.presence.run(ctx)
+	go a.broadcaster.Listen(ctx, a.deliver)
+
 	// Router principal com middlewares
 	r := chi.NewRouter()
 
@@ -60,7 +102,7 @@ func NewHandler(q *pgstore.Queries) http.Handler {
 	r.Use(custommiddleware.UserSessionMiddleware(userSessionMgr))
 
 	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"http://localhost:3000", "http://localhost:8080", "http://127.0.0.1:3000", "http://127.0.0.1:8080"},
+		AllowedOrigins:   allowedOrigins,
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"},
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-Host-Token", "Cookie"},
 		ExposedHeaders:   []string{"Set-Cookie"},
@@ -98,20 +140,47 @@ func NewHandler(q *pgstore.Queries) http.Handler {
 				"health":    "/health",
 				"api":       "/api/rooms",
 				"websocket": "/subscribe/{room_id}",
+				"multiplex": "/subscribe",
+				"lobby":     "/subscribe/lobby",
+				"lobby_sse": "/api/lobby/events",
+				"schema":    "/api/schema/events",
+				"sse":       "/api/rooms/{room_id}/events",
 			},
+			// Conexões em tempo real desta instância e seus limites
+			"connections": a.hub.stats(),
 		}
 
 		json.NewEncoder(w).Encode(status)
 	})
 
+	// Rotas de streaming ficam fora do timeout aplicado ao restante da API
+	r.With(auth.OptionalHostMiddleware(sessionMgr)).Get("/api/rooms/{room_id}/export", a.handleExportRoom)
+	r.With(auth.OptionalHostMiddleware(sessionMgr)).Get("/api/rooms/{room_id}/events", a.handleRoomEvents)
+	r.Get("/api/lobby/events", a.handleLobbyEvents)
+
 	r.Route("/api", func(r chi.Router) {
 		// Aplicar timeout apenas nas rotas da API, não no WebSocket
-		r.Use(custommiddleware.TimeoutMiddleware(custommiddleware.DefaultRequestTimeout))
+		r.Use(custommiddleware.TimeoutMiddleware(cfg.Timeouts.Request))
+
+		// Esquema dos eventos em tempo real
+		r.Get("/schema/events", a.handleGetEventSchema)
 
 		// User management routes
 		r.Route("/user", func(r chi.Router) {
 			r.Delete("/logout", a.handleUserLogout)
 			r.Get("/rooms", a.handleGetUserRooms)
+
+			r.Route("/notifications", func(r chi.Router) {
+				r.Get("/", a.handleGetNotifications)
+				r.Patch("/read", a.handleMarkAllNotificationsRead)
+				r.Patch("/{notification_id}/read", a.handleMarkNotificationRead)
+			})
+
+			r.Route("/templates", func(r chi.Router) {
+				r.Post("/", a.handleCreateRoomTemplate)
+				r.Get("/", a.handleGetRoomTemplates)
+				r.Delete("/{template_id}", a.handleDeleteRoomTemplate)
+			})
 		})
 
 		r.Route("/rooms", func(r chi.Router) {
@@ -121,12 +190,54 @@ func NewHandler(q *pgstore.Queries) http.Handler {
 			r.Route("/{room_id}", func(r chi.Router) {
 				r.Get("/", a.handleGetRoom)
 
+				// Apenas o host pode alterar o tema e a visibilidade da sala
+				r.With(auth.HostOnlyMiddleware(sessionMgr)).Patch("/", a.handleUpdateRoom)
+
+				// Cria uma nova sala a partir das configurações desta
+				r.Post("/clone", a.handleCloneRoom)
+
 				// Rota para verificar se é host (com middleware opcional)
 				r.With(auth.OptionalHostMiddleware(sessionMgr)).Get("/host-status", a.handleGetHostStatus)
 
 				// Rota para deletar sala (requer sessão de usuário - middleware já aplicado globalmente)
 				r.Delete("/", a.handleDeleteRoom)
 
+				// Apenas o host pode importar perguntas em lote
+				r.With(auth.HostOnlyMiddleware(sessionMgr)).Post("/import", a.handleImportRoomMessages)
+
+				// Webhooks da sala, gerenciados pelo host
+				r.With(auth.HostOnlyMiddleware(sessionMgr)).Route("/webhooks", func(r chi.Router) {
+					r.Post("/", a.handleCreateWebhook)
+					r.Get("/", a.handleGetWebhooks)
+					r.Delete("/{webhook_id}", a.handleDeleteWebhook)
+					r.Get("/{webhook_id}/deliveries", a.handleGetWebhookDeliveries)
+					r.Post("/{webhook_id}/deliveries/{delivery_id}/retry", a.handleRetryWebhookDelivery)
+				})
+
+				// Sessões conectadas à sala, para o host
+				r.With(auth.HostOnlyMiddleware(sessionMgr)).Get("/presence", a.handleGetRoomPresence)
+
+				// Métricas da sala para o host
+				r.With(auth.HostOnlyMiddleware(sessionMgr)).Get("/analytics", a.handleGetRoomAnalytics)
+
+				// Apenas o host pode remover o destaque da sala
+				r.With(auth.HostOnlyMiddleware(sessionMgr)).Delete("/spotlight", a.handleClearSpotlight)
+
+				r.Route("/polls", func(r chi.Router) {
+					r.Get("/", a.handleGetRoomPolls)
+
+					// Apenas o host pode criar, abrir e encerrar enquetes
+					r.With(auth.HostOnlyMiddleware(sessionMgr)).Post("/", a.handleCreatePoll)
+
+					r.Route("/{poll_id}", func(r chi.Router) {
+						r.Get("/", a.handleGetPoll)
+						r.Post("/vote", a.handleVotePoll)
+
+						r.With(auth.HostOnlyMiddleware(sessionMgr)).Patch("/open", a.handleOpenPoll)
+						r.With(auth.HostOnlyMiddleware(sessionMgr)).Patch("/close", a.handleClosePoll)
+					})
+				})
+
 				r.Route("/messages", func(r chi.Router) {
 					r.Post("/", a.handleCreateRoomMessage)
 					r.Get("/", a.handleGetRoomMessages)
@@ -136,8 +247,15 @@ func NewHandler(q *pgstore.Queries) http.Handler {
 						r.Patch("/react", a.handleReactToMessage)
 						r.Delete("/react", a.handleRemoveReactFromMessage)
 
+						// Acompanhar a pergunta para receber suas atualizações
+						r.Patch("/follow", a.handleFollowMessage)
+						r.Delete("/follow", a.handleUnfollowMessage)
+
 						// Apenas o host pode marcar mensagens como respondidas
 						r.With(auth.HostOnlyMiddleware(sessionMgr)).Patch("/answer", a.handleMarkMessageAsAnswered)
+
+						// Apenas o host pode destacar a pergunta sendo respondida
+						r.With(auth.HostOnlyMiddleware(sessionMgr)).Patch("/spotlight", a.handleSpotlightMessage)
 					})
 				})
 			})
@@ -150,7 +268,21 @@ func NewHandler(q *pgstore.Queries) http.Handler {
 	a.r = r
 
 	// Retornar um handler que separa WebSocket das outras rotas
-	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+	mux := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		// Conexão sem sala: as salas são escolhidas por comandos subscribe
+		if req.Method == "GET" && req.URL.Path == "/subscribe" {
+			logger.Default.Info(req.Context(), "WebSocket route detected", "path", req.URL.Path)
+			a.handleSubscribeMulti(w, req)
+			return
+		}
+
+		// Feed do lobby: criação, alteração, remoção e atividade das salas públicas
+		if req.Method == "GET" && req.URL.Path == "/subscribe/lobby" {
+			logger.Default.Info(req.Context(), "WebSocket route detected", "path", req.URL.Path)
+			a.handleSubscribeLobby(w, req)
+			return
+		}
+
 		// Verificar se é uma rota WebSocket usando strings.HasPrefix
 		if req.Method == "GET" && strings.HasPrefix(req.URL.Path, "/subscribe/") {
 			logger.Default.Info(req.Context(), "WebSocket route detected", "path", req.URL.Path)
@@ -161,14 +293,32 @@ func NewHandler(q *pgstore.Queries) http.Handler {
 		// Para todas as outras rotas, usar o router normal
 		a.r.ServeHTTP(w, req)
 	})
+
+	return &Handler{Handler: mux, api: a, stop: stop}
 }
 
 const (
-	MessageKindMessageCreated          = "message_created"
-	MessageKindMessageRactionIncreased = "message_reaction_increased"
-	MessageKindMessageRactionDecreased = "message_reaction_decreased"
-	MessageKindMessageAnswered         = "message_answered"
-	MessageKindRoomDeleted             = "room_deleted"
+	MessageKindMessageCreated           = "message_created"
+	MessageKindMessageReactionIncreased = "message_reaction_increased"
+	MessageKindMessageReactionDecreased = "message_reaction_decreased"
+	MessageKindMessageAnswered          = "message_answered"
+	MessageKindRoomDeleted              = "room_deleted"
+	MessageKindPollOpened               = "poll_opened"
+	MessageKindPollResultsUpdated       = "poll_results_updated"
+	MessageKindPollClosed               = "poll_closed"
+	MessageKindMessageSpotlighted       = "message_spotlighted"
+	MessageKindMessagesImported         = "messages_imported"
+	MessageKindResyncRequired           = "resync_required"
+	MessageKindPresenceUpdated          = "presence_updated"
+	MessageKindPresenceDetail           = "presence_detail"
+)
+
+// Nomes antigos, com erro de digitação, mantidos por compatibilidade
+const (
+	// Deprecated: use MessageKindMessageReactionIncreased.
+	MessageKindMessageRactionIncreased = MessageKindMessageReactionIncreased
+	// Deprecated: use MessageKindMessageReactionDecreased.
+	MessageKindMessageRactionDecreased = MessageKindMessageReactionDecreased
 )
 
 type MessageMessageReactionIncreased struct {
@@ -195,126 +345,221 @@ type MessageRoomDeleted struct {
 	Reason string `json:"reason"`
 }
 
+// MessageMessageSpotlighted carrega o destaque atual da sala; Spotlight nulo indica que foi removido
+type MessageMessageSpotlighted struct {
+	Spotlight *SpotlightResponse `json:"spotlight"`
+}
+
+type MessagePollOpened struct {
+	ID       string             `json:"id"`
+	Question string             `json:"question"`
+	Options  []PollOptionResult `json:"options"`
+}
+
+type MessagePollResultsUpdated struct {
+	ID         string             `json:"id"`
+	Options    []PollOptionResult `json:"options"`
+	TotalVotes int64              `json:"total_votes"`
+}
+
+type MessagePollClosed struct {
+	ID         string             `json:"id"`
+	Options    []PollOptionResult `json:"options"`
+	TotalVotes int64              `json:"total_votes"`
+}
+
+// MessageResyncRequired avisa que os eventos perdidos não estão mais disponíveis:
+// o cliente deve recarregar o estado da sala e continuar a partir de LastSeq
+type MessageResyncRequired struct {
+	Since   int64 `json:"since"`
+	LastSeq int64 `json:"last_seq"`
+}
+
 type Message struct {
 	Kind   string `json:"kind"`
 	Value  any    `json:"value"`
-	RoomID string `json:"-"`
+	RoomID string `json:"room_id,omitempty"`
+	// Audience restringe quem recebe o evento; o valor zero entrega a toda a sala
+	Audience Audience `json:"-"`
+	// OccurredAt é o momento do evento, enviado no envelope da versão 2 do protocolo
+	OccurredAt time.Time `json:"-"`
+	// Seq é o número sequencial do evento na sala, usado em ?since= e Last-Event-ID
+	Seq int64 `json:"seq,omitempty"`
+	// Lobby marca eventos do feed do lobby, que não pertencem a uma sala
+	Lobby bool `json:"-"`
 }
 
+// notifyClients numbers msg in the room log and publishes it to every instance;
+// each instance then delivers it to its own subscribers (see deliver)
 func (h apiHandler) notifyClients(msg Message) {
-	h.mu.Lock()
-	defer h.mu.Unlock()
+	h.notifyRoom(msg)
+}
 
-	subscribers, ok := h.subscribers[msg.RoomID]
-	if !ok || len(subscribers) == 0 {
-		logger.Default.Debug(context.Background(), "no subscribers for room", "room_id", msg.RoomID, "message_kind", msg.Kind)
-		return
+// notifyClientsNow works like notifyClients, but also delivers msg to the subscribers of this
+// instance before returning. deliver skips the copy that comes back through the broadcaster.
+func (h apiHandler) notifyClientsNow(msg Message) {
+	if sequenced, logged := h.notifyRoom(msg); logged {
+		h.deliver(sequenced)
 	}
+}
 
-	logger.Default.Debug(context.Background(), "notifying clients", "room_id", msg.RoomID, "message_kind", msg.Kind, "subscriber_count", len(subscribers))
+// notifyRoom does the work of notifyClients and returns msg with its sequence number.
+// logged is false when msg could not be added to the room log; it was then delivered to
+// this instance only.
+func (h apiHandler) notifyRoom(msg Message) (sequenced Message, logged bool) {
+	if msg.OccurredAt.IsZero() {
+		msg.OccurredAt = time.Now().UTC()
+	}
 
-	// Criar context com timeout para notificações
-	ctx, cancel := WithClientTimeout(context.Background())
+	// room_deleted é enfileirado na mesma transação que remove a sala (ver handleDeleteRoom);
+	// eventos privados não saem por webhooks
+	if msg.Kind != MessageKindRoomDeleted && msg.Audience.everyone() {
+		h.enqueueWebhooks(msg)
+	}
+
+	ctx, cancel := h.withDatabaseTimeout(context.Background())
 	defer cancel()
 
-	disconnectedClients := 0
-	for conn, cancelFunc := range subscribers {
-		// Enviar mensagem com timeout
-		done := make(chan error, 1)
-		go func() {
-			conn.SetWriteDeadline(time.Now().Add(ClientNotificationTimeout))
-			done <- conn.WriteJSON(msg)
-		}()
-
-		select {
-		case err := <-done:
-			if err != nil {
-				logger.Default.Error(context.Background(), "failed to send message to client", "room_id", msg.RoomID, "message_kind", msg.Kind, "error", err)
-				cancelFunc()
-				disconnectedClients++
-			}
-		case <-ctx.Done():
-			logger.Default.Warn(context.Background(), "timeout sending message to client", "room_id", msg.RoomID, "message_kind", msg.Kind)
-			cancelFunc()
-			disconnectedClients++
+	sequenced, committed, err := h.publishRoomEvent(ctx, msg.RoomID, func(*pgstore.Queries) (Message, error) {
+		return msg, nil
+	})
+	if err != nil {
+		logger.Default.Error(ctx, "failed to publish room event", "room_id", msg.RoomID, "message_kind", msg.Kind, "committed", committed, "error", err)
+
+		// Sem o log o evento ainda chega aos clientes desta instância, mas não poderá ser reenviado
+		if !committed {
+			h.deliver(msg)
 		}
 	}
 
-	if disconnectedClients > 0 {
-		logger.Default.Warn(context.Background(), "some clients disconnected", "room_id", msg.RoomID, "disconnected_count", disconnectedClients)
+	if msg.Kind == MessageKindRoomDeleted {
+		h.events.deleteLog(ctx, msg.RoomID)
 	}
+
+	return sequenced, committed
+}
+
+// publishRoomEvent appends the event returned by build to the log of the room and publishes it
+// with its sequence number. build runs in the transaction that appends the event, so what it
+// writes is only committed together with the event. committed reports whether that transaction
+// committed: when it did, the event is in the log even if publishing it failed.
+func (h apiHandler) publishRoomEvent(ctx context.Context, roomID string, build func(q *pgstore.Queries) (Message, error)) (msg Message, committed bool, err error) {
+	room := h.events.room(roomID)
+	room.publishMu.Lock()
+	defer room.publishMu.Unlock()
+
+	// Num broadcaster transacional o registro no log e a publicação ficam na mesma transação:
+	// a linha da sequência da sala fica travada até o commit, então os eventos são publicados
+	// na ordem dos números. Nos demais o evento só sai depois do commit, para que nenhum
+	// cliente receba um número que não chegou a ser gravado.
+	err = h.withTx(ctx, func(q *pgstore.Queries) error {
+		built, err := build(q)
+		if err != nil {
+			return err
+		}
+		if built.OccurredAt.IsZero() {
+			built.OccurredAt = time.Now().UTC()
+		}
+
+		if msg, err = h.events.append(ctx, q, built); err != nil {
+			return err
+		}
+		if !h.broadcaster.Transactional() {
+			return nil
+		}
+		return h.broadcaster.Publish(ctx, q, msg)
+	})
+	if err != nil {
+		return msg, false, err
+	}
+	if h.broadcaster.Transactional() {
+		return msg, true, nil
+	}
+
+	return msg, true, h.broadcaster.Publish(ctx, h.q, msg)
 }
 
-func (h apiHandler) handleSubscribe(w http.ResponseWriter, r *http.Request) {
-	_, rawRoomID, _, ok := h.readRoom(w, r)
-	if !ok {
+// publishEphemeral hands msg to every instance without adding it to the room log
+func (h apiHandler) publishEphemeral(msg Message) {
+	ctx, cancel := h.withDatabaseTimeout(context.Background())
+	defer cancel()
+
+	if err := h.broadcaster.Publish(ctx, h.q, msg); err != nil {
+		logger.Default.Warn(ctx, "failed to publish ephemeral event", "room_id", msg.RoomID, "message_kind", msg.Kind, "error", err)
+	}
+}
+
+// deliver sends msg to the subscribers of its room connected to this instance.
+// The event is encoded once and only enqueued; each connection writes it on its own goroutine.
+func (h apiHandler) deliver(msg Message) {
+	// Contagens de presença das outras instâncias não vão aos clientes
+	if msg.Kind == MessageKindPresenceCount {
+		h.presence.count(msg)
+		return
+	}
+
+	// Eventos do lobby e eventos pessoais não pertencem a uma sala
+	if msg.Lobby {
+		h.lobby.deliver(msg)
+		return
+	}
+	if msg.RoomID == "" {
+		h.deliverToSessions(msg)
 		return
 	}
 
-	logger.Default.Info(r.Context(), "WebSocket connection attempt", "room_id", rawRoomID, "client_ip", r.RemoteAddr)
+	h.lobby.touch(msg)
+
+	room := h.events.room(msg.RoomID)
+	room.mu.Lock()
+	defer room.mu.Unlock()
 
-	c, err := h.upgrader.Upgrade(w, r, nil)
+	if msg.Seq > 0 {
+		// Os eventos chegam na ordem dos números: um número já visto é uma segunda entrega
+		// do mesmo evento (ver notifyClientsNow)
+		if n := len(room.recent); n > 0 && msg.Seq <= room.recent[n-1].Seq {
+			return
+		}
+		h.events.remember(room, msg)
+	}
+
+	if msg.Kind == MessageKindRoomDeleted {
+		defer h.events.forget(msg.RoomID)
+	}
+
+	event, err := encodeEvent(msg)
 	if err != nil {
-		logger.Default.Warn(r.Context(), "failed to upgrade connection", "room_id", rawRoomID, "client_ip", r.RemoteAddr, "error", err)
-		http.Error(w, "failed to upgrade to ws connection", http.StatusBadRequest)
+		logger.Default.Error(context.Background(), "failed to encode room event", "room_id", msg.RoomID, "message_kind", msg.Kind, "error", err)
 		return
 	}
 
-	defer c.Close()
+	queued, evicted := h.hub.broadcast(msg.RoomID, event)
 
-	// Criar um context com timeout para a conexão WebSocket
-	ctx, cancel := context.WithCancel(r.Context())
-	defer cancel()
+	logger.Default.Debug(context.Background(), "notifying clients", "room_id", msg.RoomID, "message_kind", msg.Kind, "subscriber_count", queued)
 
-	// Adicionar room_id ao context para rastreamento
-	ctx = WithRoomID(ctx, rawRoomID)
-
-	h.mu.Lock()
-	if _, ok := h.subscribers[rawRoomID]; !ok {
-		h.subscribers[rawRoomID] = make(map[*websocket.Conn]context.CancelFunc)
+	if evicted > 0 {
+		logger.Default.Warn(context.Background(), "slow clients disconnected", "room_id", msg.RoomID, "message_kind", msg.Kind, "evicted_count", evicted)
 	}
-	logger.Default.Info(ctx, "new client connected", "room_id", rawRoomID, "client_ip", r.RemoteAddr, "total_subscribers", len(h.subscribers[rawRoomID])+1)
-	h.subscribers[rawRoomID][c] = cancel
-	h.mu.Unlock()
-
-	// Configurar timeouts para WebSocket
-	c.SetReadDeadline(time.Now().Add(WebSocketReadTimeout))
-	c.SetWriteDeadline(time.Now().Add(WebSocketWriteTimeout))
-
-	// Configurar pong handler para manter conexão viva
-	c.SetPongHandler(func(string) error {
-		c.SetReadDeadline(time.Now().Add(WebSocketReadTimeout))
-		return nil
-	})
+}
 
-	// Enviar ping periodicamente para manter conexão viva
-	go func() {
-		ticker := time.NewTicker(30 * time.Second)
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-ticker.C:
-				c.SetWriteDeadline(time.Now().Add(WebSocketWriteTimeout))
-				if err := c.WriteMessage(websocket.PingMessage, nil); err != nil {
-					logger.Default.Debug(ctx, "failed to send ping", "error", err)
-					cancel()
-					return
-				}
-			case <-ctx.Done():
-				return
-			}
-		}
-	}()
+// deliverToSessions sends a personal event to every connection of the sessions in its
+// audience held by this instance
+func (h apiHandler) deliverToSessions(msg Message) {
+	event, err := encodeEvent(msg)
+	if err != nil {
+		logger.Default.Error(context.Background(), "failed to encode personal event", "message_kind", msg.Kind, "error", err)
+		return
+	}
 
-	<-ctx.Done()
+	for _, sessionID := range msg.Audience.SessionIDs {
+		queued, evicted := h.hub.sendToSession(sessionID, event)
 
-	h.mu.Lock()
-	delete(h.subscribers[rawRoomID], c)
-	remainingSubscribers := len(h.subscribers[rawRoomID])
-	h.mu.Unlock()
+		logger.Default.Debug(context.Background(), "notifying session", "session_id", sessionID, "message_kind", msg.Kind, "connection_count", queued)
 
-	logger.Default.Info(context.Background(), "client disconnected", "room_id", rawRoomID, "client_ip", r.RemoteAddr, "remaining_subscribers", remainingSubscribers)
+		if evicted > 0 {
+			logger.Default.Warn(context.Background(), "slow clients disconnected", "session_id", sessionID, "message_kind", msg.Kind, "evicted_count", evicted)
+		}
+	}
 }
 
 // handleSubscribeRaw - Handler WebSocket completo com broadcast
@@ -326,15 +571,60 @@ func (h apiHandler) handleSubscribeRaw(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	parsedRoomID, err := uuid.Parse(roomID)
+	if err != nil {
+		http.Error(w, "invalid room id", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.q.GetRoom(r.Context(), parsedRoomID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "room not found", http.StatusNotFound)
+			return
+		}
+
+		logger.Default.Error(r.Context(), "failed to get room", "room_id", roomID, "error", err)
+		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		return
+	}
+
+	// ?since=<seq> reenvia os eventos perdidos antes de passar para o modo ao vivo
+	since, err := parseEventSeq(r.URL.Query().Get("since"))
+	if err != nil {
+		http.Error(w, "invalid since", http.StatusBadRequest)
+		return
+	}
+
 	logger.Default.Info(r.Context(), "WebSocket connection attempt", "room_id", roomID, "client_ip", r.RemoteAddr)
 
-	// Upgrader básico
-	upgrader := websocket.Upgrader{
-		CheckOrigin: func(r *http.Request) bool { return true },
+	// A identidade é resolvida antes do upgrade, enquanto a requisição HTTP ainda está disponível
+	sessionCtx := h.userSessionContext(r)
+
+	// Navegadores não enviam headers no WebSocket: o token de host também pode vir no subprotocolo.
+	// Nunca na query, que acabaria em logs de acesso, de proxies e no Referer.
+	hostToken := r.Header.Get("X-Host-Token")
+	if hostToken == "" {
+		hostToken = hostTokenFromSubprotocols(r)
+	}
+	if hostToken != "" {
+		if !h.sessionMgr.IsRoomHost(parsedRoomID, hostToken) {
+			logger.Default.Warn(r.Context(), "invalid host token on websocket connection", "room_id", roomID)
+			http.Error(w, "invalid host token", http.StatusForbidden)
+			return
+		}
+		sessionCtx = auth.WithHost(sessionCtx, hostToken, true)
+	}
+
+	// Papel de host: token válido ou sessão criadora da sala
+	isHost, err := h.isRoomHost(sessionCtx, parsedRoomID, hostToken)
+	if err != nil {
+		logger.Default.Error(r.Context(), "failed to resolve host role", "room_id", roomID, "error", err)
+		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		return
 	}
 
 	// Tentar upgrade direto
-	conn, err := upgrader.Upgrade(w, r, nil)
+	conn, err := h.upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		logger.Default.Error(r.Context(), "upgrade failed", "error", err, "room_id", roomID)
 		return
@@ -343,55 +633,60 @@ func (h apiHandler) handleSubscribeRaw(w http.ResponseWriter, r *http.Request) {
 
 	logger.Default.Info(r.Context(), "WebSocket connected successfully", "room_id", roomID, "client_ip", r.RemoteAddr)
 
-	// Context para gerenciar a conexão
-	ctx, cancel := context.WithCancel(r.Context())
-	defer cancel()
+	// Context para gerenciar a conexão, com a sessão do usuário usada pelos comandos
+	ctx := WithClientIP(WithRoomID(sessionCtx, roomID), clientIP(r))
 
-	// Registrar cliente nos subscribers
-	h.mu.Lock()
-	if _, ok := h.subscribers[roomID]; !ok {
-		h.subscribers[roomID] = make(map[*websocket.Conn]context.CancelFunc)
-	}
-	h.subscribers[roomID][conn] = cancel
-	subscriberCount := len(h.subscribers[roomID])
-	h.mu.Unlock()
+	// Registrar cliente no hub
+	sub := newWSSubscriber(conn, h.timeouts.WebSocketWrite)
 
-	logger.Default.Info(ctx, "client registered", "room_id", roomID, "total_subscribers", subscriberCount)
+	c, subscriberCount, err := h.subscribe(ctx, roomID, sub, isHost, since, config.WebSocketPingInterval)
+	if err != nil {
+		// Acima de um limite o cliente recebe o código e o motivo no quadro de fechamento
+		reason, limited := limitCloseReason(err)
+		if !limited {
+			logger.Default.Warn(ctx, "failed to replay events", "room_id", roomID, "error", err)
+		}
+		c.close(reason)
+	}
 
-	// Configurar timeouts e handlers para manter conexão viva
-	conn.SetReadDeadline(time.Now().Add(60 * time.Second))
-	conn.SetPongHandler(func(string) error {
-		conn.SetReadDeadline(time.Now().Add(60 * time.Second))
-		return nil
-	})
+	logger.Default.Info(ctx, "client registered", "room_id", roomID, "total_subscribers", subscriberCount, "anonymous", c.identity.sessionID == "", "is_host", isHost)
 
-	// Goroutine para enviar pings
-	go func() {
-		ticker := time.NewTicker(30 * time.Second)
-		defer ticker.Stop()
-		for {
-			select {
-			case <-ticker.C:
-				conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
-				if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
-					logger.Default.Debug(ctx, "failed to send ping", "error", err, "room_id", roomID)
-					cancel()
-					return
-				}
-			case <-ctx.Done():
-				return
-			}
-		}
-	}()
+	state := newWSConnection(c)
+	state.defaultRoom = roomID
+	state.rooms[roomID] = &wsRoom{id: parsedRoomID, isHost: isHost, subscribed: err == nil}
 
 	// Aguardar até a conexão ser fechada
-	<-ctx.Done()
+	h.serveWebSocket(ctx, conn, state)
+
+	logger.Default.Info(context.Background(), "client disconnected", "room_id", roomID, "client_ip", r.RemoteAddr, "remaining_subscribers", h.hub.count(roomID))
+}
+
+// handleSubscribeMulti serves /subscribe: a WebSocket connection that starts with no room.
+// The client adds and removes rooms with subscribe/unsubscribe commands carrying room_id,
+// and every event it receives carries the room_id it belongs to.
+func (h apiHandler) handleSubscribeMulti(w http.ResponseWriter, r *http.Request) {
+	logger.Default.Info(r.Context(), "multiplexed WebSocket connection attempt", "client_ip", r.RemoteAddr)
+
+	// A sessão é resolvida antes do upgrade; o papel de host vem do subscribe de cada sala
+	ctx := WithClientIP(h.userSessionContext(r), clientIP(r))
+
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		logger.Default.Error(r.Context(), "upgrade failed", "error", err)
+		return
+	}
+	defer conn.Close()
+
+	c, err := h.connect(ctx, newWSSubscriber(conn, h.timeouts.WebSocketWrite), config.WebSocketPingInterval)
+	if err != nil {
+		// Espera o quadro de fechamento com o motivo ser enviado
+		h.serveClient(ctx, c)
+		return
+	}
+
+	logger.Default.Info(ctx, "multiplexed client connected", "client_ip", r.RemoteAddr, "anonymous", c.identity.sessionID == "")
 
-	// Limpar cliente dos subscribers
-	h.mu.Lock()
-	delete(h.subscribers[roomID], conn)
-	remainingSubscribers := len(h.subscribers[roomID])
-	h.mu.Unlock()
+	h.serveWebSocket(ctx, conn, newWSConnection(c))
 
-	logger.Default.Info(context.Background(), "client disconnected", "room_id", roomID, "client_ip", r.RemoteAddr, "remaining_subscribers", remainingSubscribers)
+	logger.Default.Info(context.Background(), "multiplexed client disconnected", "client_ip", r.RemoteAddr)
 }
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"context"
+	"slices"
+
+	"github.com/JeanGrijp/ask-me-anything/internal/middleware"
+	"github.com/JeanGrijp/ask-me-anything/internal/store/pgstore"
+	"github.com/google/uuid"
+)
+
+// Audience restricts who receives an event within its room. The zero value is everyone;
+// otherwise the event reaches the hosts (when Hosts is set) and the listed user sessions.
+type Audience struct {
+	Hosts      bool     `json:"hosts,omitempty"`
+	SessionIDs []string `json:"session_ids,omitempty"`
+}
+
+// everyone reports whether the event is public
+func (a Audience) everyone() bool {
+	return !a.Hosts && len(a.SessionIDs) == 0
+}
+
+// includes reports whether a connection with the given session and role receives the event
+func (a Audience) includes(sessionID string, isHost bool) bool {
+	if a.everyone() {
+		return true
+	}
+	if a.Hosts && isHost {
+		return true
+	}
+	return sessionID != "" && slices.Contains(a.SessionIDs, sessionID)
+}
+
+// isRoomHost reports whether a connection has the host role in a room: it sent a valid host
+// token for the room, or its user session is the creator of the room
+func (h apiHandler) isRoomHost(ctx context.Context, roomID uuid.UUID, hostToken string) (bool, error) {
+	if hostToken != "" && h.sessionMgr.IsRoomHost(roomID, hostToken) {
+		return true, nil
+	}
+
+	sessionToken, ok := middleware.GetUserSessionToken(ctx)
+	if !ok {
+		return false, nil
+	}
+
+	return h.q.IsRoomCreator(ctx, pgstore.IsRoomCreatorParams{
+		RoomID:       roomID,
+		SessionToken: sessionToken,
+	})
+}
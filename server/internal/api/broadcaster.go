@@ -0,0 +1,240 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/JeanGrijp/ask-me-anything/internal/logger"
+	"github.com/JeanGrijp/ask-me-anything/internal/store/pgstore"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// Canal do LISTEN/NOTIFY usado para os eventos das salas
+	roomEventsChannel = "room_events"
+	// O NOTIFY aceita até 8000 bytes; acima disso só a referência do evento é enviada
+	maxNotifyPayloadSize = 7900
+
+	inProcessQueueSize    = 1024
+	minListenRetryDelay   = time.Second
+	maxListenRetryDelay   = 30 * time.Second
+	referenceFetchTimeout = 5 * time.Second
+)
+
+// Broadcaster carries room events to the subscribers of every server instance
+type Broadcaster interface {
+	// Publish hands msg to every instance. For a transactional broadcaster, q is bound to the
+	// transaction that appends msg to the room log and msg is only sent if that transaction
+	// commits, in commit order, which is the order of the sequence numbers. The others send msg
+	// right away, so room events are only published to them after the commit.
+	Publish(ctx context.Context, q *pgstore.Queries, msg Message) error
+	// Transactional reports whether Publish goes through the transaction bound to q
+	Transactional() bool
+	// Listen calls deliver for every message published by any instance until ctx is done
+	Listen(ctx context.Context, deliver func(Message))
+}
+
+/* ---------- In-process ---------------------------------------------------- */
+
+type inProcessBroadcaster struct {
+	queue chan Message
+}
+
+// NewInProcessBroadcaster returns a Broadcaster for a single instance
+func NewInProcessBroadcaster() Broadcaster {
+	return &inProcessBroadcaster{queue: make(chan Message, inProcessQueueSize)}
+}
+
+func (b *inProcessBroadcaster) Publish(ctx context.Context, _ *pgstore.Queries, msg Message) error {
+	select {
+	case b.queue <- msg:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *inProcessBroadcaster) Transactional() bool {
+	return false
+}
+
+func (b *inProcessBroadcaster) Listen(ctx context.Context, deliver func(Message)) {
+	for {
+		select {
+		case msg := <-b.queue:
+			deliver(msg)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+/* ---------- Postgres LISTEN/NOTIFY ---------------------------------------- */
+
+// roomEventNotification is the NOTIFY payload. Events too large for NOTIFY are sent as a
+// reference (Value empty) and read back from room_events by each instance.
+type roomEventNotification struct {
+	RoomID string          `json:"room_id"`
+	Seq    int64           `json:"seq"`
+	Kind   string          `json:"kind"`
+	Value  json.RawMessage `json:"value,omitempty"`
+	// Audience is nil for events delivered to the whole room
+	Audience   *Audience `json:"audience,omitempty"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Lobby      bool      `json:"lobby,omitempty"`
+}
+
+type postgresBroadcaster struct {
+	pool *pgxpool.Pool
+	q    *pgstore.Queries
+}
+
+// NewPostgresBroadcaster returns a Broadcaster that reaches every instance connected
+// to the same database through LISTEN/NOTIFY
+func NewPostgresBroadcaster(pool *pgxpool.Pool) Broadcaster {
+	return &postgresBroadcaster{pool: pool, q: pgstore.New(pool)}
+}
+
+func (b *postgresBroadcaster) Publish(ctx context.Context, q *pgstore.Queries, msg Message) error {
+	value, err := json.Marshal(msg.Value)
+	if err != nil {
+		return err
+	}
+
+	notification := roomEventNotification{
+		RoomID:     msg.RoomID,
+		Seq:        msg.Seq,
+		Kind:       msg.Kind,
+		Value:      value,
+		OccurredAt: msg.OccurredAt,
+		Lobby:      msg.Lobby,
+	}
+	if !msg.Audience.everyone() {
+		notification.Audience = &msg.Audience
+	}
+
+	payload, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+
+	if len(payload) > maxNotifyPayloadSize {
+		// Eventos pessoais e do lobby não ficam no log de uma sala de onde possam ser lidos
+		if msg.RoomID == "" {
+			return fmt.Errorf("event %s without room too large to publish", msg.Kind)
+		}
+
+		notification.Value = nil
+		if payload, err = json.Marshal(notification); err != nil {
+			return err
+		}
+	}
+
+	return q.NotifyRoomEvent(ctx, pgstore.NotifyRoomEventParams{
+		Channel: roomEventsChannel,
+		Payload: string(payload),
+	})
+}
+
+func (b *postgresBroadcaster) Transactional() bool {
+	return true
+}
+
+// Listen keeps a dedicated connection listening to the channel, reconnecting with backoff.
+// Events published while it is reconnecting are not delivered live; clients recover them
+// with ?since= or Last-Event-ID.
+func (b *postgresBroadcaster) Listen(ctx context.Context, deliver func(Message)) {
+	delay := minListenRetryDelay
+
+	for ctx.Err() == nil {
+		err := b.listen(ctx, deliver, func() { delay = minListenRetryDelay })
+		if ctx.Err() != nil {
+			return
+		}
+
+		logger.Default.Warn(ctx, "room events listener disconnected", "retry_in", delay.String(), "error", err)
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return
+		}
+		delay = min(delay*2, maxListenRetryDelay)
+	}
+}
+
+func (b *postgresBroadcaster) listen(ctx context.Context, deliver func(Message), connected func()) error {
+	poolConn, err := b.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+
+	// A conexão em LISTEN não volta para o pool
+	conn := poolConn.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+roomEventsChannel); err != nil {
+		return err
+	}
+
+	connected()
+	logger.Default.Info(ctx, "listening for room events", "channel", roomEventsChannel)
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		msg, err := b.decode(ctx, notification.Payload)
+		if err != nil {
+			logger.Default.Error(ctx, "failed to decode room event notification", "error", err)
+			continue
+		}
+
+		deliver(msg)
+	}
+}
+
+func (b *postgresBroadcaster) decode(ctx context.Context, payload string) (Message, error) {
+	var notification roomEventNotification
+	if err := json.Unmarshal([]byte(payload), &notification); err != nil {
+		return Message{}, err
+	}
+
+	msg := Message{
+		Kind:       notification.Kind,
+		Value:      notification.Value,
+		RoomID:     notification.RoomID,
+		Seq:        notification.Seq,
+		OccurredAt: notification.OccurredAt,
+		Lobby:      notification.Lobby,
+	}
+	if notification.Audience != nil {
+		msg.Audience = *notification.Audience
+	}
+
+	if notification.Value != nil {
+		return msg, nil
+	}
+
+	// Evento grande demais para o NOTIFY: busca no log da sala
+	roomID, err := uuid.Parse(notification.RoomID)
+	if err != nil {
+		return Message{}, err
+	}
+
+	fetchCtx, cancel := context.WithTimeout(ctx, referenceFetchTimeout)
+	defer cancel()
+
+	event, err := b.q.GetRoomEvent(fetchCtx, pgstore.GetRoomEventParams{RoomID: roomID, Seq: notification.Seq})
+	if err != nil {
+		return Message{}, fmt.Errorf("failed to fetch room event %d: %w", notification.Seq, err)
+	}
+
+	msg.Value = json.RawMessage(event.Payload)
+	return msg, nil
+}
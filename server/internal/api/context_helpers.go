@@ -3,25 +3,11 @@ package api
 
 import (
 	"context"
-	"time"
 )
 
-const (
-	// Timeouts para diferentes operações
-	DatabaseOperationTimeout  = 5 * time.Second
-	WebSocketReadTimeout      = 30 * time.Second
-	WebSocketWriteTimeout     = 10 * time.Second
-	ClientNotificationTimeout = 2 * time.Second
-)
-
-// WithDatabaseTimeout cria um context com timeout para operações de banco de dados
-func WithDatabaseTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
-	return context.WithTimeout(ctx, DatabaseOperationTimeout)
-}
-
-// WithClientTimeout cria um context com timeout para operações do cliente
-func WithClientTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
-	return context.WithTimeout(ctx, ClientNotificationTimeout)
+// withDatabaseTimeout cria um context com o timeout configurado para operações de banco de dados
+func (h apiHandler) withDatabaseTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, h.timeouts.Database)
 }
 
 // contextValue define chaves para valores no context
@@ -31,6 +17,7 @@ const (
 	RequestIDKey contextKey = "request_id"
 	UserIDKey    contextKey = "user_id"
 	RoomIDKey    contextKey = "room_id"
+	ClientIPKey  contextKey = "client_ip"
 )
 
 // GetRequestID extrai o request ID do context
@@ -58,3 +45,16 @@ func GetRoomID(ctx context.Context) string {
 	}
 	return ""
 }
+
+// WithClientIP adiciona ao context o endereço de origem de uma conexão em tempo real
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, ClientIPKey, ip)
+}
+
+// GetClientIP extrai do context o endereço de origem de uma conexão em tempo real
+func GetClientIP(ctx context.Context) string {
+	if ip, ok := ctx.Value(ClientIPKey).(string); ok {
+		return ip
+	}
+	return ""
+}
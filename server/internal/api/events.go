@@ -0,0 +1,230 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"github.com/JeanGrijp/ask-me-anything/internal/logger"
+	"github.com/JeanGrijp/ask-me-anything/internal/store/pgstore"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// Eventos recentes mantidos em memória por sala; reconexões curtas não vão ao banco
+	roomEventBufferSize = 256
+	// Eventos mantidos no banco por sala
+	roomEventRetention = 1000
+	// A poda do log no banco roda a cada tantos eventos de uma sala
+	roomEventPruneEvery = 100
+	// Acima disso o cliente deve recarregar o estado em vez de receber o reenvio
+	maxReplayEvents = 500
+)
+
+var errEventGapTooOld = errors.New("events after the requested sequence are no longer available")
+
+// roomEventLog numbers the events of each room and keeps a bounded history of them,
+// in memory and in the room_events table, so reconnecting clients can catch up
+type roomEventLog struct {
+	q     *pgstore.Queries
+	mu    sync.Mutex
+	rooms map[string]*roomEvents
+}
+
+// roomEvents is the in-memory history of one room. mu is held while an event is delivered
+// to the local subscribers and while a replayed client joins the room, so no client misses an
+// event between the replay and the live stream. The database is never read with mu held.
+type roomEvents struct {
+	mu     sync.Mutex
+	recent []Message
+	// publishMu is held from the append of an event to its publication, so a broadcaster that
+	// only sees events after the commit still receives them in the order of their numbers
+	publishMu sync.Mutex
+}
+
+func newRoomEventLog(q *pgstore.Queries) *roomEventLog {
+	return &roomEventLog{q: q, rooms: make(map[string]*roomEvents)}
+}
+
+// room returns the history of a room, creating it if needed
+func (l *roomEventLog) room(roomID string) *roomEvents {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	events, ok := l.rooms[roomID]
+	if !ok {
+		events = &roomEvents{}
+		l.rooms[roomID] = events
+	}
+	return events
+}
+
+// append stores msg in the room log and returns it with its sequence number.
+// q may be bound to a transaction; the in-memory history is updated on delivery (see remember).
+func (l *roomEventLog) append(ctx context.Context, q *pgstore.Queries, msg Message) (Message, error) {
+	roomID, err := uuid.Parse(msg.RoomID)
+	if err != nil {
+		return msg, err
+	}
+
+	payload, err := json.Marshal(msg.Value)
+	if err != nil {
+		return msg, err
+	}
+
+	// O público é gravado para que o reenvio respeite as mesmas restrições
+	var audience []byte
+	if !msg.Audience.everyone() {
+		if audience, err = json.Marshal(msg.Audience); err != nil {
+			return msg, err
+		}
+	}
+
+	seq, err := q.AppendRoomEvent(ctx, pgstore.AppendRoomEventParams{
+		RoomID:   roomID,
+		Kind:     msg.Kind,
+		Payload:  payload,
+		Audience: audience,
+	})
+	if err != nil {
+		return msg, err
+	}
+	msg.Seq = seq
+
+	if seq%roomEventPruneEvery == 0 && seq > roomEventRetention {
+		_, err := q.PruneRoomEvents(ctx, pgstore.PruneRoomEventsParams{RoomID: roomID, Seq: seq - roomEventRetention})
+		if err != nil {
+			return msg, err
+		}
+	}
+
+	return msg, nil
+}
+
+// remember adds a delivered event to the in-memory history. The caller must hold the room lock.
+func (l *roomEventLog) remember(events *roomEvents, msg Message) {
+	events.recent = append(events.recent, msg)
+	if len(events.recent) > roomEventBufferSize {
+		events.recent = events.recent[len(events.recent)-roomEventBufferSize:]
+	}
+}
+
+// since returns the events of the room after the given sequence number, together with the
+// last sequence number of the room. It returns errEventGapTooOld when some of those events
+// were already discarded or there are too many of them. The caller must not hold the room
+// lock: events delivered while the database is read are added later by catchUp.
+func (l *roomEventLog) since(ctx context.Context, rawRoomID string, since int64) ([]Message, int64, error) {
+	events := l.room(rawRoomID)
+
+	// Caminho rápido: o buffer em memória cobre o intervalo pedido
+	events.mu.Lock()
+	missed, lastSeq, ok := events.buffered(since)
+	events.mu.Unlock()
+	if ok {
+		return missed, lastSeq, nil
+	}
+
+	roomID, err := uuid.Parse(rawRoomID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	lastSeq, err = l.q.GetRoomLastEventSeq(ctx, roomID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, 0, err
+	}
+
+	if since == lastSeq {
+		return nil, lastSeq, nil
+	}
+
+	// Um número maior que o último emitido não corresponde a este log
+	if since > lastSeq || lastSeq-since > maxReplayEvents {
+		return nil, lastSeq, errEventGapTooOld
+	}
+
+	rows, err := l.q.GetRoomEventsSince(ctx, pgstore.GetRoomEventsSinceParams{
+		RoomID:    roomID,
+		Since:     since,
+		MaxEvents: maxReplayEvents,
+	})
+	if err != nil {
+		return nil, lastSeq, err
+	}
+
+	if len(rows) == 0 || rows[0].Seq != since+1 {
+		return nil, lastSeq, errEventGapTooOld
+	}
+
+	missed = make([]Message, 0, len(rows))
+	for _, row := range rows {
+		msg := Message{
+			Kind:       row.Kind,
+			Value:      json.RawMessage(row.Payload),
+			RoomID:     rawRoomID,
+			Seq:        row.Seq,
+			OccurredAt: row.CreatedAt.Time,
+		}
+		if row.Audience != nil {
+			if err := json.Unmarshal(row.Audience, &msg.Audience); err != nil {
+				return nil, lastSeq, err
+			}
+		}
+		missed = append(missed, msg)
+	}
+	return missed, lastSeq, nil
+}
+
+// catchUp returns the events delivered to this instance after seq, the last sequence number
+// returned by since, and the last sequence number of the room. ok is false when some of those
+// events are no longer in memory. The caller must hold the room lock.
+func (l *roomEventLog) catchUp(events *roomEvents, seq int64) (missed []Message, lastSeq int64, ok bool) {
+	n := len(events.recent)
+	if n == 0 || events.recent[n-1].Seq <= seq {
+		return nil, seq, true
+	}
+
+	missed, lastSeq, ok = events.buffered(seq)
+	if !ok {
+		return nil, events.recent[n-1].Seq, false
+	}
+	return missed, lastSeq, true
+}
+
+// buffered returns the events after since from the in-memory history, when it covers them.
+// The caller must hold the room lock.
+func (events *roomEvents) buffered(since int64) ([]Message, int64, bool) {
+	n := len(events.recent)
+	if n == 0 || events.recent[0].Seq > since+1 || since > events.recent[n-1].Seq {
+		return nil, 0, false
+	}
+
+	var missed []Message
+	for _, msg := range events.recent {
+		if msg.Seq > since {
+			missed = append(missed, msg)
+		}
+	}
+	return missed, events.recent[n-1].Seq, true
+}
+
+// forget drops the in-memory history of a room that no longer exists
+func (l *roomEventLog) forget(rawRoomID string) {
+	l.mu.Lock()
+	delete(l.rooms, rawRoomID)
+	l.mu.Unlock()
+}
+
+// deleteLog drops the stored history of a room that no longer exists
+func (l *roomEventLog) deleteLog(ctx context.Context, rawRoomID string) {
+	roomID, err := uuid.Parse(rawRoomID)
+	if err != nil {
+		return
+	}
+
+	if err := l.q.DeleteRoomEventLog(ctx, roomID); err != nil {
+		logger.Default.Warn(ctx, "failed to delete room event log", "room_id", rawRoomID, "error", err)
+	}
+}
@@ -0,0 +1,185 @@
+package api
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/JeanGrijp/ask-me-anything/internal/config"
+	"github.com/google/uuid"
+)
+
+// newTestEventHandler returns a handler with the in-memory parts of the event log and the hub;
+// the database is never reached while the history in memory covers what is asked
+func newTestEventHandler() apiHandler {
+	h := apiHandler{events: newRoomEventLog(nil), hub: newTestHub(config.ConnectionLimits{}), timeouts: config.Default().Timeouts}
+	h.lobby = newLobby(nil, h.hub, h.timeouts.Database)
+	return h
+}
+
+func testMessage(roomID string, seq int64) Message {
+	return Message{Kind: MessageKindMessageCreated, RoomID: roomID, Seq: seq, Value: map[string]int64{"seq": seq}}
+}
+
+func seqs(msgs []Message) []int64 {
+	out := make([]int64, 0, len(msgs))
+	for _, msg := range msgs {
+		out = append(out, msg.Seq)
+	}
+	return out
+}
+
+func TestDeliverKeepsOrderAndSkipsDuplicates(t *testing.T) {
+	roomID := uuid.NewString()
+	h := newTestEventHandler()
+	c := joinTestClient(t, h.hub, roomID, &membership{})
+
+	// O evento 2 chega duas vezes: pela entrega local e pelo broadcaster
+	for _, seq := range []int64{1, 2, 2, 3} {
+		h.deliver(testMessage(roomID, seq))
+	}
+
+	if got := seqs(h.events.room(roomID).recent); !slices.Equal(got, []int64{1, 2, 3}) {
+		t.Errorf("history = %v, want [1 2 3]", got)
+	}
+
+	var queued []int64
+	for len(c.queue) > 0 {
+		queued = append(queued, (<-c.queue).seq)
+	}
+	if !slices.Equal(queued, []int64{1, 2, 3}) {
+		t.Errorf("client received %v, want [1 2 3]", queued)
+	}
+}
+
+func TestSinceFromBuffer(t *testing.T) {
+	roomID := uuid.NewString()
+	h := newTestEventHandler()
+	for seq := int64(10); seq <= 14; seq++ {
+		h.deliver(testMessage(roomID, seq))
+	}
+
+	for _, tc := range []struct {
+		since int64
+		want  []int64
+	}{
+		{since: 9, want: []int64{10, 11, 12, 13, 14}},
+		{since: 12, want: []int64{13, 14}},
+		{since: 14, want: []int64{}},
+	} {
+		missed, lastSeq, err := h.events.since(context.Background(), roomID, tc.since)
+		if err != nil {
+			t.Fatalf("since(%d): %v", tc.since, err)
+		}
+		if got := seqs(missed); !slices.Equal(got, tc.want) {
+			t.Errorf("since(%d) = %v, want %v", tc.since, got, tc.want)
+		}
+		if lastSeq != 14 {
+			t.Errorf("since(%d) last seq = %d, want 14", tc.since, lastSeq)
+		}
+	}
+}
+
+func TestBufferedRefusesGaps(t *testing.T) {
+	events := &roomEvents{}
+	for seq := int64(10); seq <= 12; seq++ {
+		events.recent = append(events.recent, testMessage("", seq))
+	}
+
+	// Antes do início do buffer, ou depois do último evento, a resposta vem do banco
+	for _, since := range []int64{0, 8, 13} {
+		if _, _, ok := events.buffered(since); ok {
+			t.Errorf("buffered(%d) claimed to cover events it does not hold", since)
+		}
+	}
+}
+
+func TestCatchUpAddsEventsDeliveredDuringReplay(t *testing.T) {
+	events := &roomEvents{}
+	log := newRoomEventLog(nil)
+
+	// Nada foi entregue ainda: o que o banco devolveu está completo
+	if missed, lastSeq, ok := log.catchUp(events, 20); !ok || len(missed) != 0 || lastSeq != 20 {
+		t.Fatalf("empty history: missed %v, last seq %d, ok %t", seqs(missed), lastSeq, ok)
+	}
+
+	for seq := int64(18); seq <= 23; seq++ {
+		log.remember(events, testMessage("", seq))
+	}
+
+	missed, lastSeq, ok := log.catchUp(events, 20)
+	if !ok || !slices.Equal(seqs(missed), []int64{21, 22, 23}) || lastSeq != 23 {
+		t.Fatalf("catchUp(20) = %v, %d, %t; want [21 22 23], 23, true", seqs(missed), lastSeq, ok)
+	}
+
+	if missed, lastSeq, ok := log.catchUp(events, 23); !ok || len(missed) != 0 || lastSeq != 23 {
+		t.Errorf("catchUp(23) = %v, %d, %t; want nothing new", seqs(missed), lastSeq, ok)
+	}
+}
+
+func TestCatchUpReportsGap(t *testing.T) {
+	events := &roomEvents{}
+	log := newRoomEventLog(nil)
+	for seq := int64(1); seq <= roomEventBufferSize+10; seq++ {
+		log.remember(events, testMessage("", seq))
+	}
+
+	// Os eventos logo após 5 já saíram do buffer
+	_, lastSeq, ok := log.catchUp(events, 5)
+	if ok {
+		t.Fatal("catchUp covered events no longer buffered")
+	}
+	if lastSeq != roomEventBufferSize+10 {
+		t.Errorf("last seq = %d, want %d", lastSeq, roomEventBufferSize+10)
+	}
+}
+
+func TestReplayTailRequestsResyncOnGap(t *testing.T) {
+	roomID := uuid.NewString()
+	h := newTestEventHandler()
+	for seq := int64(1); seq <= roomEventBufferSize+10; seq++ {
+		h.deliver(testMessage(roomID, seq))
+	}
+
+	room := h.events.room(roomID)
+	room.mu.Lock()
+	events, lastSeq, err := h.replayTail(context.Background(), room, roomID, 3, 5, "", false)
+	room.mu.Unlock()
+	if err != nil {
+		t.Fatalf("replayTail: %v", err)
+	}
+
+	if len(events) != 1 || events[0].seq != roomEventBufferSize+10 {
+		t.Fatalf("replayTail returned %d events, want a single resync_required", len(events))
+	}
+	if lastSeq != roomEventBufferSize+10 {
+		t.Errorf("last seq = %d, want %d", lastSeq, roomEventBufferSize+10)
+	}
+}
+
+func TestEncodeReplayFiltersAudienceInOrder(t *testing.T) {
+	hostOnly := testMessage("", 2)
+	hostOnly.Audience = Audience{Hosts: true}
+	mine := testMessage("", 3)
+	mine.Audience = Audience{SessionIDs: []string{"me"}}
+
+	missed := []Message{testMessage("", 1), hostOnly, mine, testMessage("", 4), hostOnly}
+	missed[4].Seq = 5
+
+	events, lastSeq, err := encodeReplay(missed, 0, "me", false)
+	if err != nil {
+		t.Fatalf("encodeReplay: %v", err)
+	}
+
+	var got []int64
+	for _, event := range events {
+		got = append(got, event.seq)
+	}
+	if !slices.Equal(got, []int64{1, 3, 4}) {
+		t.Errorf("replayed %v, want [1 3 4]", got)
+	}
+	// Eventos de outro público não são enviados, mas contam como vistos
+	if lastSeq != 5 {
+		t.Errorf("last seq = %d, want 5", lastSeq)
+	}
+}
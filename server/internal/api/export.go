@@ -0,0 +1,303 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/JeanGrijp/ask-me-anything/internal/auth"
+	"github.com/JeanGrijp/ask-me-anything/internal/logger"
+	"github.com/JeanGrijp/ask-me-anything/internal/store/pgstore"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	ExportFormatCSV      = "csv"
+	ExportFormatJSON     = "json"
+	ExportFormatMarkdown = "md"
+
+	// Quantidade de linhas escritas entre cada flush para o cliente
+	exportFlushEvery = 500
+)
+
+// exportWriter writes one export format row by row
+type exportWriter interface {
+	begin(room pgstore.Room) error
+	row(message pgstore.GetRoomExportRow) error
+	flush() error
+	end() error
+}
+
+// handleExportRoom streams every message of a room as CSV, JSON or Markdown (host or creator only)
+func (h apiHandler) handleExportRoom(w http.ResponseWriter, r *http.Request) {
+	room, rawRoomID, roomID, ok := h.readRoom(w, r)
+	if !ok {
+		return
+	}
+
+	// O host é identificado pelo X-Host-Token; o criador, pela sessão
+	if !auth.IsHost(r.Context()) {
+		isCreator, err := h.isRoomCreator(r, roomID)
+		if err != nil {
+			logger.Default.Error(r.Context(), "failed to check room creator", "room_id", rawRoomID, "error", err)
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		if !isCreator {
+			logger.Default.Warn(r.Context(), "room export denied", "room_id", rawRoomID)
+			http.Error(w, "only room host or creator can export this room", http.StatusForbidden)
+			return
+		}
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = ExportFormatCSV
+	}
+
+	var (
+		contentType string
+		writer      exportWriter
+	)
+	switch format {
+	case ExportFormatCSV:
+		contentType = "text/csv; charset=utf-8"
+		writer = &csvExportWriter{w: csv.NewWriter(w)}
+	case ExportFormatJSON:
+		contentType = "application/json"
+		writer = &jsonExportWriter{w: w}
+	case ExportFormatMarkdown:
+		contentType = "text/markdown; charset=utf-8"
+		writer = &markdownExportWriter{w: w}
+	default:
+		http.Error(w, "format must be one of csv, json or md", http.StatusBadRequest)
+		return
+	}
+
+	logger.Default.Info(r.Context(), "exporting room", "room_id", rawRoomID, "format", format)
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+		"filename": exportFilename(room.Theme, format),
+	}))
+
+	rows, err := h.streamExport(r, w, writer, room)
+	if err != nil {
+		// Os cabeçalhos já foram enviados; só resta registrar o erro
+		logger.Default.Error(r.Context(), "failed to export room", "room_id", rawRoomID, "format", format, "rows", rows, "error", err)
+		return
+	}
+
+	logger.Default.Info(r.Context(), "room exported successfully", "room_id", rawRoomID, "format", format, "rows", rows)
+}
+
+// streamExport writes the room messages as they are read from the database,
+// flushing to the client every exportFlushEvery rows
+func (h apiHandler) streamExport(r *http.Request, w http.ResponseWriter, writer exportWriter, room pgstore.Room) (int, error) {
+	rc := http.NewResponseController(w)
+	rows := 0
+
+	if err := writer.begin(room); err != nil {
+		return rows, err
+	}
+
+	err := h.q.StreamRoomExport(r.Context(), room.ID, func(message pgstore.GetRoomExportRow) error {
+		if err := writer.row(message); err != nil {
+			return err
+		}
+
+		rows++
+		if rows%exportFlushEvery == 0 {
+			if err := writer.flush(); err != nil {
+				return err
+			}
+			_ = rc.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return rows, err
+	}
+
+	return rows, writer.end()
+}
+
+// Tamanho máximo, em bytes, do nome do arquivo antes do sufixo
+const maxExportFilenameLength = 100
+
+// exportFilename builds a download file name from the room theme
+func exportFilename(theme, format string) string {
+	var b strings.Builder
+	dash := false
+	for _, c := range strings.ToLower(theme) {
+		if unicode.IsLetter(c) || unicode.IsDigit(c) {
+			b.WriteRune(c)
+			dash = false
+			continue
+		}
+		if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+
+	name := strings.TrimSuffix(b.String(), "-")
+	if name == "" {
+		name = "room"
+	}
+	if len(name) > maxExportFilenameLength {
+		// Corta no início de uma runa, para não partir um caractere acentuado ao meio
+		cut := maxExportFilenameLength
+		for cut > 0 && !utf8.RuneStart(name[cut]) {
+			cut--
+		}
+		name = strings.TrimSuffix(name[:cut], "-")
+	}
+
+	return name + "-questions." + format
+}
+
+func formatExportTime(t pgtype.Timestamp) string {
+	if !t.Valid {
+		return ""
+	}
+	return formatTimestamp(t.Time)
+}
+
+/* ---------- CSV ----------------------------------------------------------- */
+
+type csvExportWriter struct {
+	w *csv.Writer
+}
+
+func (e *csvExportWriter) begin(pgstore.Room) error {
+	return e.w.Write([]string{"id", "message", "reaction_count", "answered", "created_at", "answered_at"})
+}
+
+func (e *csvExportWriter) row(m pgstore.GetRoomExportRow) error {
+	return e.w.Write([]string{
+		m.ID.String(),
+		m.Message,
+		strconv.FormatInt(m.ReactionCount, 10),
+		strconv.FormatBool(m.Answered),
+		formatExportTime(m.CreatedAt),
+		formatExportTime(m.AnsweredAt),
+	})
+}
+
+func (e *csvExportWriter) flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvExportWriter) end() error {
+	return e.flush()
+}
+
+/* ---------- JSON ---------------------------------------------------------- */
+
+type jsonExportMessage struct {
+	ID            string `json:"id"`
+	Message       string `json:"message"`
+	ReactionCount int64  `json:"reaction_count"`
+	Answered      bool   `json:"answered"`
+	CreatedAt     string `json:"created_at"`
+	AnsweredAt    string `json:"answered_at,omitempty"`
+}
+
+// jsonExportWriter writes {"room": {...}, "messages": [...]} one message at a time
+type jsonExportWriter struct {
+	w     io.Writer
+	count int
+}
+
+func (e *jsonExportWriter) begin(room pgstore.Room) error {
+	data, err := json.Marshal(room)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(e.w, "{\"room\":%s,\"messages\":[", data)
+	return err
+}
+
+func (e *jsonExportWriter) row(m pgstore.GetRoomExportRow) error {
+	data, err := json.Marshal(jsonExportMessage{
+		ID:            m.ID.String(),
+		Message:       m.Message,
+		ReactionCount: m.ReactionCount,
+		Answered:      m.Answered,
+		CreatedAt:     formatExportTime(m.CreatedAt),
+		AnsweredAt:    formatExportTime(m.AnsweredAt),
+	})
+	if err != nil {
+		return err
+	}
+
+	if e.count > 0 {
+		if _, err := io.WriteString(e.w, ","); err != nil {
+			return err
+		}
+	}
+	e.count++
+
+	_, err = e.w.Write(data)
+	return err
+}
+
+func (e *jsonExportWriter) flush() error { return nil }
+
+func (e *jsonExportWriter) end() error {
+	_, err := io.WriteString(e.w, "]}\n")
+	return err
+}
+
+/* ---------- Markdown ------------------------------------------------------ */
+
+type markdownExportWriter struct {
+	w     io.Writer
+	count int
+}
+
+var markdownEscaper = strings.NewReplacer("|", "\\|", "\r\n", "<br>", "\n", "<br>")
+
+func (e *markdownExportWriter) begin(room pgstore.Room) error {
+	_, err := fmt.Fprintf(e.w,
+		"# %s\n\n| # | Question | Reactions | Answered | Asked at | Answered at |\n|---|---|---|---|---|---|\n",
+		markdownEscaper.Replace(room.Theme),
+	)
+	return err
+}
+
+func (e *markdownExportWriter) row(m pgstore.GetRoomExportRow) error {
+	e.count++
+
+	answered := "no"
+	if m.Answered {
+		answered = "yes"
+	}
+
+	_, err := fmt.Fprintf(e.w, "| %d | %s | %d | %s | %s | %s |\n",
+		e.count,
+		markdownEscaper.Replace(m.Message),
+		m.ReactionCount,
+		answered,
+		formatExportTime(m.CreatedAt),
+		formatExportTime(m.AnsweredAt),
+	)
+	return err
+}
+
+func (e *markdownExportWriter) flush() error { return nil }
+
+func (e *markdownExportWriter) end() error {
+	_, err := fmt.Fprintf(e.w, "\n_%d questions_\n", e.count)
+	return err
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"mime"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestExportFilename(t *testing.T) {
+	for theme, want := range map[string]string{
+		"Town Hall Q3 2026":    "town-hall-q3-2026-questions.csv",
+		"  Perguntas: Gestão!": "perguntas-gestão-questions.csv",
+		"***":                  "room-questions.csv",
+	} {
+		if got := exportFilename(theme, "csv"); got != want {
+			t.Errorf("exportFilename(%q) = %q, want %q", theme, got, want)
+		}
+	}
+}
+
+func TestExportFilenameTruncatesOnRuneBoundary(t *testing.T) {
+	// "é" ocupa dois bytes: com um prefixo ímpar, o corte em 100 bytes cairia no meio de um
+	theme := "a" + strings.Repeat("é", 120)
+
+	name := exportFilename(theme, "csv")
+	if !utf8.ValidString(name) {
+		t.Fatalf("exportFilename produced invalid UTF-8: %q", name)
+	}
+	if slug := strings.TrimSuffix(name, "-questions.csv"); len(slug) > maxExportFilenameLength {
+		t.Errorf("slug has %d bytes, want at most %d", len(slug), maxExportFilenameLength)
+	}
+
+	// O Content-Disposition leva o nome como está, sem bytes escapados de uma runa partida
+	disposition := mime.FormatMediaType("attachment", map[string]string{"filename": name})
+	if _, params, err := mime.ParseMediaType(disposition); err != nil || params["filename"] != name {
+		t.Errorf("Content-Disposition %q does not round-trip the file name: %v", disposition, err)
+	}
+}
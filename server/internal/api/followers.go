@@ -0,0 +1,155 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/JeanGrijp/ask-me-anything/internal/logger"
+	"github.com/JeanGrijp/ask-me-anything/internal/middleware"
+	"github.com/JeanGrijp/ask-me-anything/internal/store/pgstore"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Mudanças de uma pergunta avisadas a quem a acompanha
+const (
+	MessageKindFollowedQuestionAnswered    = "followed_question_answered"
+	MessageKindFollowedQuestionSpotlighted = "followed_question_spotlighted"
+)
+
+// Sessões por evento pessoal, para que o NOTIFY fique abaixo do limite de tamanho
+const maxSessionsPerEvent = 100
+
+// MessageFollowedQuestion tells the followers of a question that it changed; the event kind
+// says how. Like your_question_answered it is a personal event, also stored in the inbox.
+type MessageFollowedQuestion struct {
+	RoomID    string `json:"room_id"`
+	MessageID string `json:"message_id"`
+	Message   string `json:"message"`
+}
+
+// FollowResponse is the follow state of a message for the current session
+type FollowResponse struct {
+	Following bool  `json:"following"`
+	Followers int64 `json:"followers"`
+}
+
+func (h apiHandler) handleFollowMessage(w http.ResponseWriter, r *http.Request) {
+	h.setFollowing(w, r, true)
+}
+
+func (h apiHandler) handleUnfollowMessage(w http.ResponseWriter, r *http.Request) {
+	h.setFollowing(w, r, false)
+}
+
+// setFollowing makes the current session follow or stop following a message of the room
+func (h apiHandler) setFollowing(w http.ResponseWriter, r *http.Request, follow bool) {
+	_, rawRoomID, roomID, ok := h.readRoom(w, r)
+	if !ok {
+		return
+	}
+
+	rawID := chi.URLParam(r, "message_id")
+	id, err := uuid.Parse(rawID)
+	if err != nil {
+		logger.Default.Warn(r.Context(), "invalid message ID in follow request", "message_id", rawID, "error", err)
+		http.Error(w, "invalid message id", http.StatusBadRequest)
+		return
+	}
+
+	session, hasSession := middleware.GetUserSessionFromContext(r.Context())
+	if !hasSession {
+		logger.Default.Warn(r.Context(), "no user session found for follow", "room_id", rawRoomID, "message_id", rawID)
+		http.Error(w, "session required", http.StatusUnauthorized)
+		return
+	}
+
+	message, err := h.q.GetMessage(r.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && message.RoomID != roomID) {
+		logger.Default.Warn(r.Context(), "message not found for follow", "room_id", rawRoomID, "message_id", rawID)
+		http.Error(w, "message not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Default.Error(r.Context(), "failed to get message for follow", "room_id", rawRoomID, "message_id", rawID, "error", err)
+		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		return
+	}
+
+	if follow {
+		err = h.q.FollowMessage(r.Context(), pgstore.FollowMessageParams{MessageID: id, SessionID: session.ID})
+	} else {
+		_, err = h.q.UnfollowMessage(r.Context(), pgstore.UnfollowMessageParams{MessageID: id, SessionID: session.ID})
+	}
+	if err != nil {
+		logger.Default.Error(r.Context(), "failed to update message follow", "room_id", rawRoomID, "message_id", rawID, "follow", follow, "error", err)
+		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		return
+	}
+
+	followers, err := h.q.CountMessageFollowers(r.Context(), id)
+	if err != nil {
+		logger.Default.Error(r.Context(), "failed to count message followers", "room_id", rawRoomID, "message_id", rawID, "error", err)
+		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		return
+	}
+
+	logger.Default.Info(r.Context(), "message follow updated", "room_id", rawRoomID, "message_id", rawID, "follow", follow, "followers", followers)
+
+	sendJSON(w, FollowResponse{Following: follow, Followers: followers})
+}
+
+// notifyFollowers stores a notification of kind for every follower of a message and delivers
+// it live to their sessions. Each follower is notified once per kind; the author is left out
+// of followed_question_answered, since your_question_answered already tells them. Only the
+// answered and spotlighted kinds exist: questions cannot be merged or dismissed yet.
+func (h apiHandler) notifyFollowers(messageID uuid.UUID, kind string) {
+	ctx, cancel := h.withDatabaseTimeout(context.Background())
+	defer cancel()
+
+	sessionIDs, err := h.q.InsertFollowerNotifications(ctx, pgstore.InsertFollowerNotificationsParams{
+		MessageID:     messageID,
+		Kind:          kind,
+		ExcludeAuthor: kind == MessageKindFollowedQuestionAnswered,
+	})
+	if err != nil {
+		logger.Default.Error(ctx, "failed to store follower notifications", "message_id", messageID.String(), "message_kind", kind, "error", err)
+		return
+	}
+	if len(sessionIDs) == 0 {
+		return
+	}
+
+	message, err := h.q.GetMessage(ctx, messageID)
+	if err != nil {
+		logger.Default.Error(ctx, "failed to get followed message", "message_id", messageID.String(), "error", err)
+		return
+	}
+
+	logger.Default.Debug(ctx, "notifying message followers", "message_id", messageID.String(), "message_kind", kind, "follower_count", len(sessionIDs))
+
+	value := MessageFollowedQuestion{
+		RoomID:    message.RoomID.String(),
+		MessageID: messageID.String(),
+		Message:   message.Message,
+	}
+	occurredAt := time.Now().UTC()
+
+	for chunk := range slices.Chunk(sessionIDs, maxSessionsPerEvent) {
+		audience := make([]string, 0, len(chunk))
+		for _, sessionID := range chunk {
+			audience = append(audience, sessionID.String())
+		}
+
+		h.notifySessions(ctx, Message{
+			Kind:       kind,
+			Value:      value,
+			Audience:   Audience{SessionIDs: audience},
+			OccurredAt: occurredAt,
+		})
+	}
+}
@@ -0,0 +1,580 @@
+package api
+
+import (
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/JeanGrijp/ask-me-anything/internal/config"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// As salas são distribuídas entre shards para que salas diferentes não disputem o mesmo lock
+	hubShardCount = 64
+	// Eventos aguardando envio por conexão; quem acumula mais que isso é desconectado
+	clientSendQueueSize = 256
+	// Respostas a comandos e reenvios aguardando envio por conexão
+	clientControlQueueSize = 16
+)
+
+// closeReason tells a client why the server is closing its connection.
+// The zero value closes silently (e.g. the client already went away).
+type closeReason struct {
+	code int
+	text string
+	// drain writes the events already queued before closing
+	drain bool
+}
+
+var closeReasonSlowConsumer = closeReason{code: websocket.CloseTryAgainLater, text: "slow consumer"}
+
+// closeReasonShutdown disconnects the clients when the server stops; the events already
+// queued, such as server_shutting_down, are written before the close frame
+var closeReasonShutdown = closeReason{code: websocket.CloseServiceRestart, text: "server shutting down", drain: true}
+
+// client is one connection registered in the hub, possibly in several rooms. Broadcasts
+// only enqueue events; the client's writer goroutine (run) is the only one writing to the connection.
+type client struct {
+	sub subscriber
+	// identity is who is behind the connection, resolved when it is established
+	identity connIdentity
+	queue    chan encodedEvent
+	// control carries command replies and replays, written before anything in queue
+	control chan []encodedEvent
+
+	done    chan struct{}
+	stopped chan struct{}
+
+	mu     sync.Mutex
+	closed bool
+	reason closeReason
+	// rooms are the rooms the client is registered in
+	rooms map[string]struct{}
+}
+
+// membership is the registration of a client in one room
+type membership struct {
+	// lastSeq is the last event replayed to the client; live events up to it are skipped
+	lastSeq int64
+	isHost  bool
+}
+
+func newClient(sub subscriber, identity connIdentity) *client {
+	return &client{
+		sub:      sub,
+		identity: identity,
+		queue:    make(chan encodedEvent, clientSendQueueSize),
+		control:  make(chan []encodedEvent, clientControlQueueSize),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+		rooms:    make(map[string]struct{}),
+	}
+}
+
+// close asks the writer goroutine to stop, sending reason to the client. Safe to call many times.
+func (c *client) close(reason closeReason) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return
+	}
+	c.closed = true
+	c.reason = reason
+	close(c.done)
+}
+
+// roomIDs returns the rooms the client is registered in
+func (c *client) roomIDs() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	roomIDs := make([]string, 0, len(c.rooms))
+	for roomID := range c.rooms {
+		roomIDs = append(roomIDs, roomID)
+	}
+	return roomIDs
+}
+
+// send enqueues events addressed to this client only, such as command replies.
+// Like broadcasts it never blocks: a client that does not keep up is evicted.
+func (c *client) send(events ...encodedEvent) bool {
+	select {
+	case <-c.done:
+		return false
+	default:
+	}
+
+	select {
+	case c.control <- events:
+		return true
+	default:
+		c.close(closeReasonSlowConsumer)
+		return false
+	}
+}
+
+// run writes every queued event, pinging the client while idle
+func (c *client) run(pingInterval time.Duration) {
+	defer close(c.stopped)
+
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+
+	for {
+		// Respostas e reenvios têm prioridade: um reenvio precisa sair antes dos eventos ao vivo
+		select {
+		case events := <-c.control:
+			if !c.writeAll(events) {
+				return
+			}
+			continue
+		default:
+		}
+
+		select {
+		case events := <-c.control:
+			if !c.writeAll(events) {
+				return
+			}
+		case event := <-c.queue:
+			if err := c.sub.write(event); err != nil {
+				c.close(closeReason{})
+				return
+			}
+		case <-ticker.C:
+			if err := c.sub.ping(); err != nil {
+				c.close(closeReason{})
+				return
+			}
+		case <-c.done:
+			if c.reason.drain && !c.drain() {
+				return
+			}
+			if c.reason.code != 0 {
+				c.sub.close(c.reason)
+			}
+			return
+		}
+	}
+}
+
+// drain writes what is left in the queues, replies and replays first
+func (c *client) drain() bool {
+	for {
+		select {
+		case events := <-c.control:
+			if !c.writeAll(events) {
+				return false
+			}
+			continue
+		default:
+		}
+
+		select {
+		case event := <-c.queue:
+			if err := c.sub.write(event); err != nil {
+				return false
+			}
+		default:
+			return true
+		}
+	}
+}
+
+func (c *client) writeAll(events []encodedEvent) bool {
+	for _, event := range events {
+		if err := c.sub.write(event); err != nil {
+			c.close(closeReason{})
+			return false
+		}
+	}
+	return true
+}
+
+// enqueue queues a broadcast event without blocking. A client whose queue is full is
+// evicted instead (evicted is true); a client being closed is skipped.
+func (c *client) enqueue(event encodedEvent) (queued, evicted bool) {
+	// Já está sendo desconectado
+	select {
+	case <-c.done:
+		return false, false
+	default:
+	}
+
+	select {
+	case c.queue <- event:
+		return true, false
+	default:
+		c.close(closeReasonSlowConsumer)
+		return false, true
+	}
+}
+
+// hub keeps the clients of each room connected to this instance
+type hub struct {
+	shards  [hubShardCount]hubShard
+	samples *subscriberSampler
+	limits  config.ConnectionLimits
+
+	// clients holds every connected client; sessions indexes them by user session, for
+	// personal events, and ips counts them by address. Once shuttingDown is set, new clients
+	// are closed right away.
+	clientsMu    sync.RWMutex
+	clients      map[*client]struct{}
+	sessions     map[string]map[*client]struct{}
+	ips          map[string]int
+	shuttingDown bool
+
+	rejected connRejections
+
+	// lobby holds the clients following the lobby feed
+	lobbyMu sync.RWMutex
+	lobby   map[*client]struct{}
+}
+
+type hubShard struct {
+	mu    sync.RWMutex
+	rooms map[string]map[*client]*membership
+}
+
+func newHub(samples *subscriberSampler, limits config.ConnectionLimits) *hub {
+	h := &hub{
+		samples:  samples,
+		limits:   limits,
+		clients:  make(map[*client]struct{}),
+		sessions: make(map[string]map[*client]struct{}),
+		ips:      make(map[string]int),
+		lobby:    make(map[*client]struct{}),
+	}
+	for i := range h.shards {
+		h.shards[i].rooms = make(map[string]map[*client]*membership)
+	}
+	return h
+}
+
+func (h *hub) shard(roomID string) *hubShard {
+	hash := fnv.New32a()
+	hash.Write([]byte(roomID))
+	return &h.shards[hash.Sum32()%hubShardCount]
+}
+
+// join registers c in a room and returns how many clients the room has. A client already
+// closed is not registered (ok is false), so removing the rooms of a closed client is final.
+func (h *hub) join(roomID string, c *client, m *membership) (count int, ok bool) {
+	shard := h.shard(roomID)
+
+	shard.mu.Lock()
+	clients := shard.rooms[roomID]
+
+	// A ordem dos locks é sempre shard e depois cliente
+	c.mu.Lock()
+	if !c.closed {
+		if clients == nil {
+			clients = make(map[*client]*membership)
+			shard.rooms[roomID] = clients
+		}
+		clients[c] = m
+		c.rooms[roomID] = struct{}{}
+		ok = true
+	}
+	c.mu.Unlock()
+
+	count = len(clients)
+	shard.mu.Unlock()
+
+	if ok {
+		h.samples.observe(roomID, count)
+	}
+
+	return count, ok
+}
+
+// leave removes c from a room and returns how many clients remain; ok is false if c was not in it
+func (h *hub) leave(roomID string, c *client) (count int, ok bool) {
+	shard := h.shard(roomID)
+
+	shard.mu.Lock()
+	clients := shard.rooms[roomID]
+	_, ok = clients[c]
+	delete(clients, c)
+	count = len(clients)
+	if count == 0 {
+		delete(shard.rooms, roomID)
+	}
+
+	c.mu.Lock()
+	delete(c.rooms, roomID)
+	c.mu.Unlock()
+
+	shard.mu.Unlock()
+
+	if ok {
+		h.samples.observe(roomID, count)
+	}
+
+	return count, ok
+}
+
+// checkRoom returns an error when the room already has as many clients as allowed. Callers
+// hold the room lock of the event log, which orders joins and leaves of the room.
+func (h *hub) checkRoom(roomID string) *connLimitError {
+	if h.limits.PerRoom > 0 && h.count(roomID) >= h.limits.PerRoom {
+		err := &connLimitError{scope: connLimitRoom}
+		h.rejected.add(err)
+		return err
+	}
+	return nil
+}
+
+// count returns how many clients of the room are connected to this instance
+func (h *hub) count(roomID string) int {
+	shard := h.shard(roomID)
+
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+
+	return len(shard.rooms[roomID])
+}
+
+// broadcast enqueues event for every client of the room in its audience, without blocking.
+// Clients whose queue is full are evicted instead of slowing the room down.
+func (h *hub) broadcast(roomID string, event encodedEvent) (queued, evicted int) {
+	shard := h.shard(roomID)
+
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+
+	for c, m := range shard.rooms[roomID] {
+		if event.seq > 0 && event.seq <= m.lastSeq {
+			continue
+		}
+
+		if !event.audience.includes(c.identity.sessionID, m.isHost) {
+			continue
+		}
+
+		ok, slow := c.enqueue(event)
+		if ok {
+			queued++
+		}
+		if slow {
+			evicted++
+		}
+	}
+
+	return queued, evicted
+}
+
+// register adds c to the hub and indexes it by its user session and address. A client over
+// the limits of its session or address is closed with the reason and a *connLimitError is
+// returned; during shutdown c is closed as well.
+func (h *hub) register(c *client) error {
+	h.clientsMu.Lock()
+	defer h.clientsMu.Unlock()
+
+	if h.shuttingDown {
+		c.close(closeReasonShutdown)
+		return nil
+	}
+
+	if err := h.checkLimitsLocked(c.identity); err != nil {
+		h.rejected.add(err)
+		c.close(err.closeReason())
+		return err
+	}
+
+	h.clients[c] = struct{}{}
+	if c.identity.ip != "" {
+		h.ips[c.identity.ip]++
+	}
+
+	// Conexões anônimas não recebem eventos pessoais
+	if c.identity.sessionID == "" {
+		return nil
+	}
+
+	clients := h.sessions[c.identity.sessionID]
+	if clients == nil {
+		clients = make(map[*client]struct{})
+		h.sessions[c.identity.sessionID] = clients
+	}
+	clients[c] = struct{}{}
+	return nil
+}
+
+// admit checks, without registering anything, whether a connection with this identity would
+// be accepted in the room (or with no room, when roomID is empty). It lets SSE requests be
+// rejected before the stream starts; register and checkRoom still enforce the limits.
+func (h *hub) admit(identity connIdentity, roomID string) *connLimitError {
+	h.clientsMu.RLock()
+	err := h.checkLimitsLocked(identity)
+	h.clientsMu.RUnlock()
+
+	if err != nil {
+		h.rejected.add(err)
+		return err
+	}
+	if roomID != "" {
+		return h.checkRoom(roomID)
+	}
+	return nil
+}
+
+func (h *hub) checkLimitsLocked(identity connIdentity) *connLimitError {
+	if h.limits.PerSession > 0 && identity.sessionID != "" && len(h.sessions[identity.sessionID]) >= h.limits.PerSession {
+		return &connLimitError{scope: connLimitSession}
+	}
+	if h.limits.PerIP > 0 && identity.ip != "" && h.ips[identity.ip] >= h.limits.PerIP {
+		return &connLimitError{scope: connLimitIP}
+	}
+	return nil
+}
+
+// unregister removes c from the hub and from the session index
+func (h *hub) unregister(c *client) {
+	h.clientsMu.Lock()
+	defer h.clientsMu.Unlock()
+
+	if _, ok := h.clients[c]; !ok {
+		return
+	}
+	delete(h.clients, c)
+
+	if c.identity.ip != "" {
+		h.ips[c.identity.ip]--
+		if h.ips[c.identity.ip] == 0 {
+			delete(h.ips, c.identity.ip)
+		}
+	}
+
+	if c.identity.sessionID == "" {
+		return
+	}
+
+	clients := h.sessions[c.identity.sessionID]
+	delete(clients, c)
+	if len(clients) == 0 {
+		delete(h.sessions, c.identity.sessionID)
+	}
+}
+
+// sendToSession enqueues event for every client of a user session, in any room or none,
+// without blocking
+func (h *hub) sendToSession(sessionID string, event encodedEvent) (queued, evicted int) {
+	h.clientsMu.RLock()
+	defer h.clientsMu.RUnlock()
+
+	for c := range h.sessions[sessionID] {
+		ok, slow := c.enqueue(event)
+		if ok {
+			queued++
+		}
+		if slow {
+			evicted++
+		}
+	}
+
+	return queued, evicted
+}
+
+// joinLobby registers c in the lobby feed; like join, it refuses a closed client
+func (h *hub) joinLobby(c *client) (count int, ok bool) {
+	h.lobbyMu.Lock()
+	defer h.lobbyMu.Unlock()
+
+	c.mu.Lock()
+	if !c.closed {
+		h.lobby[c] = struct{}{}
+		ok = true
+	}
+	c.mu.Unlock()
+
+	return len(h.lobby), ok
+}
+
+// leaveLobby removes c from the lobby feed, if it was there
+func (h *hub) leaveLobby(c *client) {
+	h.lobbyMu.Lock()
+	defer h.lobbyMu.Unlock()
+
+	delete(h.lobby, c)
+}
+
+// lobbyCount returns how many clients of this instance follow the lobby feed
+func (h *hub) lobbyCount() int {
+	h.lobbyMu.RLock()
+	defer h.lobbyMu.RUnlock()
+
+	return len(h.lobby)
+}
+
+// broadcastLobby enqueues event for every client of the lobby feed, without blocking
+func (h *hub) broadcastLobby(event encodedEvent) (queued, evicted int) {
+	h.lobbyMu.RLock()
+	defer h.lobbyMu.RUnlock()
+
+	for c := range h.lobby {
+		ok, slow := c.enqueue(event)
+		if ok {
+			queued++
+		}
+		if slow {
+			evicted++
+		}
+	}
+
+	return queued, evicted
+}
+
+// shutdown sends event to every client and closes them all, refusing new clients from then
+// on. It returns the closed clients, so the caller can wait for them to be written.
+func (h *hub) shutdown(event encodedEvent) []*client {
+	h.clientsMu.Lock()
+	defer h.clientsMu.Unlock()
+
+	h.shuttingDown = true
+
+	clients := make([]*client, 0, len(h.clients))
+	for c := range h.clients {
+		c.enqueue(event)
+		c.close(closeReasonShutdown)
+		clients = append(clients, c)
+	}
+	return clients
+}
+
+// stats returns the connection usage of this instance
+func (h *hub) stats() ConnectionStats {
+	stats := ConnectionStats{
+		Limits:   h.limits,
+		Rooms:    ConnectionUsage{Rejected: h.rejected.room.Load()},
+		Sessions: ConnectionUsage{Rejected: h.rejected.session.Load()},
+		IPs:      ConnectionUsage{Rejected: h.rejected.ip.Load()},
+	}
+
+	h.clientsMu.RLock()
+	stats.Connections = len(h.clients)
+	stats.Sessions.Count = len(h.sessions)
+	for _, clients := range h.sessions {
+		stats.Sessions.Max = max(stats.Sessions.Max, len(clients))
+	}
+	stats.IPs.Count = len(h.ips)
+	for _, count := range h.ips {
+		stats.IPs.Max = max(stats.IPs.Max, count)
+	}
+	h.clientsMu.RUnlock()
+
+	for i := range h.shards {
+		shard := &h.shards[i]
+		shard.mu.RLock()
+		stats.Rooms.Count += len(shard.rooms)
+		for _, clients := range shard.rooms {
+			stats.Rooms.Max = max(stats.Rooms.Max, len(clients))
+		}
+		shard.mu.RUnlock()
+	}
+
+	return stats
+}
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/JeanGrijp/ask-me-anything/internal/config"
+	"github.com/google/uuid"
+)
+
+// nopSubscriber is a transport that accepts every write
+type nopSubscriber struct{}
+
+func (nopSubscriber) write(encodedEvent) error { return nil }
+func (nopSubscriber) ping() error              { return nil }
+func (nopSubscriber) close(closeReason)        {}
+
+func newTestHub(limits config.ConnectionLimits) *hub {
+	samples := &subscriberSampler{current: make(map[uuid.UUID]int), peaks: make(map[uuid.UUID]int)}
+	return newHub(samples, limits)
+}
+
+// joinTestClient registers a client in the room without starting its writer goroutine,
+// so its queue only fills up
+func joinTestClient(t *testing.T, h *hub, roomID string, m *membership) *client {
+	t.Helper()
+	c := newClient(nopSubscriber{}, connIdentity{ip: "192.0.2.1"})
+	if err := h.register(c); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if _, ok := h.join(roomID, c, m); !ok {
+		t.Fatal("join refused an open client")
+	}
+	return c
+}
+
+func testEvent(t *testing.T, roomID string, seq int64) encodedEvent {
+	t.Helper()
+	event, err := encodeEvent(Message{Kind: MessageKindMessageCreated, RoomID: roomID, Seq: seq, Value: map[string]string{"id": "x"}})
+	if err != nil {
+		t.Fatalf("encodeEvent: %v", err)
+	}
+	return event
+}
+
+func isClosed(c *client) bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestBroadcastEvictsSlowClient(t *testing.T) {
+	roomID := uuid.NewString()
+	h := newTestHub(config.ConnectionLimits{})
+	slow := joinTestClient(t, h, roomID, &membership{})
+	fast := joinTestClient(t, h, roomID, &membership{})
+
+	for seq := int64(1); seq <= clientSendQueueSize; seq++ {
+		if queued, evicted := h.broadcast(roomID, testEvent(t, roomID, seq)); queued != 2 || evicted != 0 {
+			t.Fatalf("event %d: queued %d, evicted %d; want 2 and 0", seq, queued, evicted)
+		}
+		// O cliente rápido esvazia a fila; o lento não lê nada
+		<-fast.queue
+	}
+
+	queued, evicted := h.broadcast(roomID, testEvent(t, roomID, clientSendQueueSize+1))
+	if queued != 1 || evicted != 1 {
+		t.Fatalf("full queue: queued %d, evicted %d; want 1 and 1", queued, evicted)
+	}
+	if !isClosed(slow) {
+		t.Fatal("slow client was not closed")
+	}
+	if slow.reason != closeReasonSlowConsumer {
+		t.Errorf("slow client closed with %+v, want %+v", slow.reason, closeReasonSlowConsumer)
+	}
+	if isClosed(fast) {
+		t.Error("client keeping up was closed")
+	}
+
+	// Um cliente já desconectado é ignorado até sair da sala
+	if queued, evicted := h.broadcast(roomID, testEvent(t, roomID, clientSendQueueSize+2)); queued != 1 || evicted != 0 {
+		t.Errorf("after eviction: queued %d, evicted %d; want 1 and 0", queued, evicted)
+	}
+}
+
+func TestSendEvictsClientWithFullControlQueue(t *testing.T) {
+	c := newClient(nopSubscriber{}, connIdentity{})
+	for range clientControlQueueSize {
+		if !c.send(testEvent(t, "", 0)) {
+			t.Fatal("send failed before the control queue was full")
+		}
+	}
+
+	if c.send(testEvent(t, "", 0)) {
+		t.Fatal("send succeeded on a full control queue")
+	}
+	if !isClosed(c) || c.reason != closeReasonSlowConsumer {
+		t.Errorf("client closed = %t with %+v, want closed as slow consumer", isClosed(c), c.reason)
+	}
+}
+
+func TestBroadcastSkipsReplayedEvents(t *testing.T) {
+	roomID := uuid.NewString()
+	h := newTestHub(config.ConnectionLimits{})
+	c := joinTestClient(t, h, roomID, &membership{lastSeq: 5})
+
+	for seq := int64(4); seq <= 7; seq++ {
+		h.broadcast(roomID, testEvent(t, roomID, seq))
+	}
+
+	if got := len(c.queue); got != 2 {
+		t.Fatalf("queued %d events, want the 2 after the replay", got)
+	}
+	for _, want := range []int64{6, 7} {
+		if event := <-c.queue; event.seq != want {
+			t.Errorf("queued seq %d, want %d", event.seq, want)
+		}
+	}
+}
+
+func TestJoinRefusesClosedClient(t *testing.T) {
+	roomID := uuid.NewString()
+	h := newTestHub(config.ConnectionLimits{})
+	c := newClient(nopSubscriber{}, connIdentity{})
+	c.close(closeReasonSlowConsumer)
+
+	if _, ok := h.join(roomID, c, &membership{}); ok {
+		t.Fatal("closed client joined the room")
+	}
+	if count := h.count(roomID); count != 0 {
+		t.Errorf("room has %d clients, want 0", count)
+	}
+}
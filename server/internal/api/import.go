@@ -0,0 +1,312 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/JeanGrijp/ask-me-anything/internal/logger"
+	"github.com/JeanGrijp/ask-me-anything/internal/responses"
+	"github.com/JeanGrijp/ask-me-anything/internal/store/pgstore"
+)
+
+const (
+	maxImportRows     = 1000
+	maxImportBodySize = 2 << 20 // 2 MiB
+)
+
+// ImportRowError describes why one row of an import was rejected.
+// Row is 1-based and counts only data rows (the CSV header is not a row).
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ImportedMessage is a message created by an import
+type ImportedMessage struct {
+	ID            string `json:"id"`
+	Message       string `json:"message"`
+	ReactionCount int64  `json:"reaction_count"`
+	Answered      bool   `json:"answered"`
+}
+
+// ImportResult is the response of an import
+type ImportResult struct {
+	Count    int               `json:"count"`
+	Messages []ImportedMessage `json:"messages"`
+}
+
+// MessageMessagesImported announces the messages created by an import. It carries only their
+// IDs, in the order of the import; clients fetch the messages themselves.
+type MessageMessagesImported struct {
+	Count      int      `json:"count"`
+	MessageIDs []string `json:"message_ids"`
+}
+
+// importRow is one row of an import before validation
+type importRow struct {
+	Message       string `json:"message"`
+	ReactionCount *int64 `json:"reaction_count"`
+	Answered      *bool  `json:"answered"`
+}
+
+// handleImportRoomMessages seeds a room with questions sent as CSV or JSON (host only).
+// Every row is validated before anything is written; if any row is invalid nothing is imported.
+func (h apiHandler) handleImportRoomMessages(w http.ResponseWriter, r *http.Request) {
+	_, rawRoomID, roomID, ok := h.readRoom(w, r)
+	if !ok {
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		switch mediaType {
+		case "text/csv":
+			format = ExportFormatCSV
+		case "application/json", "":
+			format = ExportFormatJSON
+		}
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBodySize)
+
+	var (
+		rows     []importRow
+		rowErrs  []ImportRowError
+		parseErr error
+	)
+	switch format {
+	case ExportFormatCSV:
+		rows, rowErrs, parseErr = parseImportCSV(r.Body)
+	case ExportFormatJSON:
+		rows, parseErr = parseImportJSON(r.Body)
+	default:
+		http.Error(w, "content type must be text/csv or application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	if parseErr != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(parseErr, &maxBytesErr) {
+			http.Error(w, "import is too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		logger.Default.Warn(r.Context(), "invalid import body", "room_id", rawRoomID, "format", format, "error", parseErr)
+		http.Error(w, "invalid "+format+": "+parseErr.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if len(rows) == 0 {
+		http.Error(w, "import has no rows", http.StatusBadRequest)
+		return
+	}
+
+	if len(rows) > maxImportRows {
+		http.Error(w, fmt.Sprintf("import can have at most %d rows", maxImportRows), http.StatusBadRequest)
+		return
+	}
+
+	params := pgstore.InsertImportedMessagesParams{
+		RoomID:         roomID,
+		Messages:       make([]string, 0, len(rows)),
+		ReactionCounts: make([]int64, 0, len(rows)),
+		Answered:       make([]bool, 0, len(rows)),
+	}
+	for i, row := range rows {
+		rowErrs = append(rowErrs, validateImportRow(i+1, row)...)
+
+		params.Messages = append(params.Messages, strings.TrimSpace(row.Message))
+		params.ReactionCounts = append(params.ReactionCounts, valueOr(row.ReactionCount, 0))
+		params.Answered = append(params.Answered, valueOr(row.Answered, false))
+	}
+
+	if len(rowErrs) > 0 {
+		sort.SliceStable(rowErrs, func(i, j int) bool { return rowErrs[i].Row < rowErrs[j].Row })
+
+		logger.Default.Warn(r.Context(), "import rejected", "room_id", rawRoomID, "rows", len(rows), "errors", len(rowErrs))
+		responses.JSON(w, http.StatusUnprocessableEntity, map[string]any{
+			"status":  "error",
+			"message": "Import has invalid rows",
+			"errors":  rowErrs,
+		})
+		return
+	}
+
+	dbCtx, cancel := h.withDatabaseTimeout(r.Context())
+	defer cancel()
+
+	// Todas as linhas entram em uma única instrução: ou todas são importadas, ou nenhuma
+	inserted, err := h.q.InsertImportedMessages(dbCtx, params)
+	if err != nil {
+		logger.Default.Error(r.Context(), "failed to import messages", "room_id", rawRoomID, "rows", len(rows), "error", err)
+		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		return
+	}
+
+	messages := make([]ImportedMessage, 0, len(inserted))
+	ids := make([]string, 0, len(inserted))
+	for _, m := range inserted {
+		ids = append(ids, m.ID.String())
+		messages = append(messages, ImportedMessage{
+			ID:            m.ID.String(),
+			Message:       m.Message,
+			ReactionCount: m.ReactionCount,
+			Answered:      m.Answered,
+		})
+	}
+
+	logger.Default.Info(r.Context(), "messages imported successfully", "room_id", rawRoomID, "format", format, "count", len(messages))
+
+	responses.JSON(w, http.StatusCreated, ImportResult{Count: len(messages), Messages: messages})
+
+	// Um único evento para o lote, em vez de um message_created por mensagem. Acima do limite
+	// do NOTIFY, o broadcaster o publica por referência ao log da sala.
+	go h.notifyClients(Message{
+		Kind:   MessageKindMessagesImported,
+		RoomID: rawRoomID,
+		Value:  MessageMessagesImported{Count: len(ids), MessageIDs: ids},
+	})
+}
+
+// parseImportJSON accepts either an array of rows or an object with a "messages"
+// array, which is also the shape produced by the JSON export
+func parseImportJSON(body io.Reader) ([]importRow, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []importRow
+	if err := json.Unmarshal(data, &rows); err == nil {
+		return rows, nil
+	}
+
+	var wrapped struct {
+		Messages []importRow `json:"messages"`
+	}
+	if err := json.Unmarshal(data, &wrapped); err != nil {
+		return nil, err
+	}
+
+	return wrapped.Messages, nil
+}
+
+// parseImportCSV reads a CSV with a header row. Only the "message" column is
+// required; "reaction_count" and "answered" are optional and other columns (such
+// as the ones written by the CSV export) are ignored.
+func parseImportCSV(body io.Reader) ([]importRow, []ImportRowError, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	messageCol, ok := columns["message"]
+	if !ok {
+		return nil, nil, errors.New(`missing "message" column`)
+	}
+	reactionCol, hasReactions := columns["reaction_count"]
+	answeredCol, hasAnswered := columns["answered"]
+
+	var (
+		rows    []importRow
+		rowErrs []ImportRowError
+	)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		rowNumber := len(rows) + 1
+		field := func(col int) string {
+			if col < len(record) {
+				return strings.TrimSpace(record[col])
+			}
+			return ""
+		}
+
+		row := importRow{Message: field(messageCol)}
+
+		if raw := field(reactionCol); hasReactions && raw != "" {
+			count, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil {
+				rowErrs = append(rowErrs, ImportRowError{Row: rowNumber, Field: "reaction_count", Message: "must be an integer"})
+			} else {
+				row.ReactionCount = &count
+			}
+		}
+
+		if raw := field(answeredCol); hasAnswered && raw != "" {
+			answered, ok := parseImportBool(raw)
+			if !ok {
+				rowErrs = append(rowErrs, ImportRowError{Row: rowNumber, Field: "answered", Message: "must be true or false"})
+			} else {
+				row.Answered = &answered
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, rowErrs, nil
+}
+
+func parseImportBool(raw string) (bool, bool) {
+	switch strings.ToLower(raw) {
+	case "true", "yes", "y", "1":
+		return true, true
+	case "false", "no", "n", "0":
+		return false, true
+	}
+	return false, false
+}
+
+func validateImportRow(rowNumber int, row importRow) []ImportRowError {
+	var errs []ImportRowError
+
+	message := strings.TrimSpace(row.Message)
+	if message == "" {
+		errs = append(errs, ImportRowError{Row: rowNumber, Field: "message", Message: "is required"})
+	} else if utf8.RuneCountInString(message) > maxMessageLength {
+		errs = append(errs, ImportRowError{Row: rowNumber, Field: "message", Message: fmt.Sprintf("must have at most %d characters", maxMessageLength)})
+	}
+
+	if row.ReactionCount != nil && *row.ReactionCount < 0 {
+		errs = append(errs, ImportRowError{Row: rowNumber, Field: "reaction_count", Message: "must not be negative"})
+	}
+
+	return errs
+}
+
+func valueOr[T any](v *T, fallback T) T {
+	if v == nil {
+		return fallback
+	}
+	return *v
+}
@@ -0,0 +1,182 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JeanGrijp/ask-me-anything/internal/store/pgstore"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestValidateImportRow(t *testing.T) {
+	negative := int64(-1)
+	zero := int64(0)
+
+	for _, tc := range []struct {
+		name string
+		row  importRow
+		want []ImportRowError
+	}{
+		{name: "valid", row: importRow{Message: "  What is next?  ", ReactionCount: &zero}},
+		{name: "empty", row: importRow{Message: "   "}, want: []ImportRowError{{Row: 7, Field: "message", Message: "is required"}}},
+		{
+			name: "too long",
+			row:  importRow{Message: strings.Repeat("a", maxMessageLength+1)},
+			want: []ImportRowError{{Row: 7, Field: "message", Message: "must have at most 255 characters"}},
+		},
+		{name: "at the limit", row: importRow{Message: strings.Repeat("a", maxMessageLength)}},
+		{name: "multibyte at the limit", row: importRow{Message: strings.Repeat("é", maxMessageLength)}},
+		{
+			name: "multibyte too long",
+			row:  importRow{Message: strings.Repeat("é", maxMessageLength+1)},
+			want: []ImportRowError{{Row: 7, Field: "message", Message: "must have at most 255 characters"}},
+		},
+		{
+			name: "negative reactions",
+			row:  importRow{Message: "ok", ReactionCount: &negative},
+			want: []ImportRowError{{Row: 7, Field: "reaction_count", Message: "must not be negative"}},
+		},
+		{
+			name: "every field invalid",
+			row:  importRow{ReactionCount: &negative},
+			want: []ImportRowError{
+				{Row: 7, Field: "message", Message: "is required"},
+				{Row: 7, Field: "reaction_count", Message: "must not be negative"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := validateImportRow(7, tc.row); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("validateImportRow = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseImportCSV(t *testing.T) {
+	body := "id,Message,reaction_count,answered\n" +
+		"a,First question,3,yes\n" +
+		"b,  Second question  ,,\n" +
+		"c,Third,lots,maybe\n" +
+		"d,Fourth\n"
+
+	rows, rowErrs, err := parseImportCSV(strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("parseImportCSV: %v", err)
+	}
+
+	if len(rows) != 4 {
+		t.Fatalf("parsed %d rows, want 4", len(rows))
+	}
+	if rows[0].Message != "First question" || valueOr(rows[0].ReactionCount, -1) != 3 || !valueOr(rows[0].Answered, false) {
+		t.Errorf("row 1 = %+v", rows[0])
+	}
+	if rows[1].Message != "Second question" || rows[1].ReactionCount != nil || rows[1].Answered != nil {
+		t.Errorf("row 2 = %+v, want empty optional columns left unset", rows[1])
+	}
+	// Linhas mais curtas que o cabeçalho não derrubam a importação
+	if rows[3].Message != "Fourth" {
+		t.Errorf("row 4 = %+v", rows[3])
+	}
+
+	want := []ImportRowError{
+		{Row: 3, Field: "reaction_count", Message: "must be an integer"},
+		{Row: 3, Field: "answered", Message: "must be true or false"},
+	}
+	if !reflect.DeepEqual(rowErrs, want) {
+		t.Errorf("row errors = %+v, want %+v", rowErrs, want)
+	}
+}
+
+func TestParseImportCSVRequiresMessageColumn(t *testing.T) {
+	if _, _, err := parseImportCSV(strings.NewReader("question\nWhat?\n")); err == nil {
+		t.Fatal("accepted a CSV without a message column")
+	}
+}
+
+func TestParseImportJSONShapes(t *testing.T) {
+	for _, body := range []string{
+		`[{"message":"One"},{"message":"Two","answered":true}]`,
+		`{"room_id":"x","messages":[{"message":"One"},{"message":"Two","answered":true}]}`,
+	} {
+		rows, err := parseImportJSON(strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("parseImportJSON(%s): %v", body, err)
+		}
+		if len(rows) != 2 || rows[0].Message != "One" || !valueOr(rows[1].Answered, false) {
+			t.Errorf("parseImportJSON(%s) = %+v", body, rows)
+		}
+	}
+
+	if _, err := parseImportJSON(strings.NewReader(`"messages"`)); err == nil {
+		t.Error("accepted a JSON body that is neither an array nor an object")
+	}
+}
+
+// notifyRecorder is a pgstore.DBTX that keeps the payloads sent to pg_notify
+type notifyRecorder struct {
+	payloads []string
+}
+
+func (db *notifyRecorder) Exec(_ context.Context, _ string, args ...any) (pgconn.CommandTag, error) {
+	db.payloads = append(db.payloads, args[1].(string))
+	return pgconn.NewCommandTag("SELECT 1"), nil
+}
+
+func (db *notifyRecorder) Query(context.Context, string, ...any) (pgx.Rows, error) {
+	return nil, errors.New("not supported")
+}
+
+func (db *notifyRecorder) QueryRow(context.Context, string, ...any) pgx.Row {
+	return nil
+}
+
+func TestImportEventPublishedByReference(t *testing.T) {
+	ids := make([]string, maxImportRows)
+	for i := range ids {
+		ids[i] = uuid.NewString()
+	}
+
+	// Um único evento com todos os IDs, mesmo acima do limite do NOTIFY
+	msg := Message{
+		Kind:       MessageKindMessagesImported,
+		RoomID:     uuid.NewString(),
+		Seq:        7,
+		Value:      MessageMessagesImported{Count: len(ids), MessageIDs: ids},
+		OccurredAt: time.Now(),
+	}
+	value, err := json.Marshal(msg.Value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(value) <= maxNotifyPayloadSize {
+		t.Fatalf("a full import has only %d bytes; the test does not reach the NOTIFY limit", len(value))
+	}
+
+	db := &notifyRecorder{}
+	if err := (&postgresBroadcaster{}).Publish(context.Background(), pgstore.New(db), msg); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if len(db.payloads) != 1 {
+		t.Fatalf("published %d notifications, want 1", len(db.payloads))
+	}
+	if len(db.payloads[0]) > maxNotifyPayloadSize {
+		t.Errorf("notification has %d bytes, over the NOTIFY limit of %d", len(db.payloads[0]), maxNotifyPayloadSize)
+	}
+
+	var notification roomEventNotification
+	if err := json.Unmarshal([]byte(db.payloads[0]), &notification); err != nil {
+		t.Fatal(err)
+	}
+	// Sem valor, cada instância lê o evento do log da sala pelo seq
+	if notification.Value != nil || notification.Seq != msg.Seq || notification.Kind != MessageKindMessagesImported {
+		t.Errorf("notification = %+v, want a reference to seq %d", notification, msg.Seq)
+	}
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/JeanGrijp/ask-me-anything/internal/config"
+	"github.com/gorilla/websocket"
+)
+
+// Escopos de um limite de conexões
+const (
+	connLimitRoom    = "room"
+	connLimitSession = "session"
+	connLimitIP      = "ip"
+)
+
+// connLimitError rejects a connection, or a room subscription, over one of the limits
+type connLimitError struct {
+	scope string
+}
+
+func (e *connLimitError) Error() string {
+	switch e.scope {
+	case connLimitRoom:
+		return "room is full"
+	case connLimitSession:
+		return "too many connections for this session"
+	default:
+		return "too many connections from this address"
+	}
+}
+
+// closeReason tells a WebSocket client why it was rejected. A full room may have room later;
+// the other limits only clear when the client closes connections of its own.
+func (e *connLimitError) closeReason() closeReason {
+	if e.scope == connLimitRoom {
+		return closeReason{code: websocket.CloseTryAgainLater, text: e.Error()}
+	}
+	return closeReason{code: websocket.ClosePolicyViolation, text: e.Error()}
+}
+
+// connRejections counts the connections rejected by each limit since the instance started
+type connRejections struct {
+	room    atomic.Int64
+	session atomic.Int64
+	ip      atomic.Int64
+}
+
+func (r *connRejections) add(err *connLimitError) {
+	switch err.scope {
+	case connLimitRoom:
+		r.room.Add(1)
+	case connLimitSession:
+		r.session.Add(1)
+	case connLimitIP:
+		r.ip.Add(1)
+	}
+}
+
+// ConnectionStats is the realtime connection usage of this instance, published on /status
+type ConnectionStats struct {
+	Connections int                     `json:"connections"`
+	Limits      config.ConnectionLimits `json:"limits"`
+	Rooms       ConnectionUsage         `json:"rooms"`
+	Sessions    ConnectionUsage         `json:"sessions"`
+	IPs         ConnectionUsage         `json:"ips"`
+}
+
+// ConnectionUsage summarizes the connections of one limit scope
+type ConnectionUsage struct {
+	// Count is how many rooms, sessions or addresses have connections
+	Count int `json:"count"`
+	// Max is the highest number of connections of a single one of them
+	Max int `json:"max"`
+	// Rejected is how many connections this limit has rejected
+	Rejected int64 `json:"rejected"`
+}
+
+// clientIP returns the address a request comes from. X-Forwarded-For is not trusted here:
+// it would let a client choose the address its connections are counted against.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// rejectStream answers a Server-Sent Events request over a limit before the stream starts
+func rejectStream(w http.ResponseWriter, err *connLimitError) {
+	w.Header().Set("Retry-After", fmt.Sprint(int(sseRetryInterval.Seconds())))
+	http.Error(w, err.Error(), http.StatusTooManyRequests)
+}
+
+// limitCloseReason returns the reason a client rejected by err is closed with. Only limit
+// errors are explained to the client; other failures close it silently.
+func limitCloseReason(err error) (closeReason, bool) {
+	var limitErr *connLimitError
+	if errors.As(err, &limitErr) {
+		return limitErr.closeReason(), true
+	}
+	return closeReason{}, false
+}
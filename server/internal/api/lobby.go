@@ -0,0 +1,280 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/JeanGrijp/ask-me-anything/internal/config"
+	"github.com/JeanGrijp/ask-me-anything/internal/logger"
+	"github.com/JeanGrijp/ask-me-anything/internal/store/pgstore"
+	"github.com/google/uuid"
+)
+
+const (
+	MessageKindRoomCreated  = "room_created"
+	MessageKindRoomUpdated  = "room_updated"
+	MessageKindRoomActivity = "room_activity"
+
+	// Salas públicas aparecem na listagem e no lobby; as não listadas só pelo link
+	RoomVisibilityPublic   = "public"
+	RoomVisibilityUnlisted = "unlisted"
+
+	// Intervalo mínimo entre dois room_activity da mesma sala
+	lobbyActivityInterval = 5 * time.Second
+)
+
+type MessageRoomCreated struct {
+	ID    string `json:"id"`
+	Theme string `json:"theme"`
+}
+
+type MessageRoomUpdated struct {
+	ID         string `json:"id"`
+	Theme      string `json:"theme"`
+	Visibility string `json:"visibility"`
+}
+
+// MessageRoomActivity carries the live counters of a public room shown in the lobby
+type MessageRoomActivity struct {
+	ID        string `json:"id"`
+	Questions int64  `json:"questions"`
+	Answered  int64  `json:"answered"`
+	Reactions int64  `json:"reactions"`
+}
+
+// Eventos de sala que mudam os contadores exibidos no lobby
+var lobbyActivityKinds = map[string]bool{
+	MessageKindMessageCreated:           true,
+	MessageKindMessageReactionIncreased: true,
+	MessageKindMessageReactionDecreased: true,
+	MessageKindMessageAnswered:          true,
+	MessageKindMessagesImported:         true,
+}
+
+func isRoomVisibility(visibility string) bool {
+	return visibility == RoomVisibilityPublic || visibility == RoomVisibilityUnlisted
+}
+
+// lobby streams the lifecycle and activity of public rooms to the clients of the lobby feed.
+// Lifecycle events are published to every instance. Activity is derived by each instance from
+// the room events it already receives, at most once per room and interval, so a busy room
+// costs the lobby one event per interval.
+type lobby struct {
+	q        *pgstore.Queries
+	hub      *hub
+	interval time.Duration
+	// dbTimeout bounds the query of each flush
+	dbTimeout time.Duration
+
+	mu sync.Mutex
+	// dirty are the rooms with activity since the last flush
+	dirty map[uuid.UUID]struct{}
+}
+
+func newLobby(q *pgstore.Queries, hub *hub, dbTimeout time.Duration) *lobby {
+	return &lobby{
+		q:         q,
+		hub:       hub,
+		interval:  lobbyActivityInterval,
+		dbTimeout: dbTimeout,
+		dirty:     make(map[uuid.UUID]struct{}),
+	}
+}
+
+// touch records that a room event changed the counters of its room
+func (l *lobby) touch(msg Message) {
+	if !lobbyActivityKinds[msg.Kind] {
+		return
+	}
+
+	roomID, err := uuid.Parse(msg.RoomID)
+	if err != nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.dirty[roomID] = struct{}{}
+}
+
+// run sends the activity of the touched rooms every interval until ctx is done
+func (l *lobby) run(ctx context.Context) {
+	ticker := time.NewTicker(l.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			l.flush(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// flush sends room_activity for every public room touched since the last flush
+func (l *lobby) flush(ctx context.Context) {
+	l.mu.Lock()
+	roomIDs := make([]uuid.UUID, 0, len(l.dirty))
+	for roomID := range l.dirty {
+		roomIDs = append(roomIDs, roomID)
+	}
+	clear(l.dirty)
+	l.mu.Unlock()
+
+	// Sem salas alteradas ou sem ninguém no lobby desta instância, não há o que enviar
+	if len(roomIDs) == 0 || l.hub.lobbyCount() == 0 {
+		return
+	}
+
+	dbCtx, cancel := context.WithTimeout(ctx, l.dbTimeout)
+	defer cancel()
+
+	// Salas não listadas ficam de fora da consulta
+	rooms, err := l.q.GetRoomsActivity(dbCtx, roomIDs)
+	if err != nil {
+		logger.Default.Error(ctx, "failed to get lobby activity", "room_count", len(roomIDs), "error", err)
+		return
+	}
+
+	occurredAt := time.Now().UTC()
+	for _, room := range rooms {
+		l.deliver(Message{
+			Kind: MessageKindRoomActivity,
+			Value: MessageRoomActivity{
+				ID:        room.ID.String(),
+				Questions: room.MessageCount,
+				Answered:  room.AnsweredCount,
+				Reactions: room.ReactionCount,
+			},
+			OccurredAt: occurredAt,
+			Lobby:      true,
+		})
+	}
+}
+
+// deliver sends a lobby event to the lobby clients connected to this instance
+func (l *lobby) deliver(msg Message) {
+	event, err := encodeEvent(msg)
+	if err != nil {
+		logger.Default.Error(context.Background(), "failed to encode lobby event", "message_kind", msg.Kind, "error", err)
+		return
+	}
+
+	queued, evicted := l.hub.broadcastLobby(event)
+
+	logger.Default.Debug(context.Background(), "notifying lobby", "message_kind", msg.Kind, "subscriber_count", queued)
+
+	if evicted > 0 {
+		logger.Default.Warn(context.Background(), "slow clients disconnected", "message_kind", msg.Kind, "evicted_count", evicted)
+	}
+}
+
+// notifyLobby publishes a lifecycle event of a public room to the lobby feed of every instance
+func (h apiHandler) notifyLobby(msg Message) {
+	msg.Lobby = true
+	if msg.OccurredAt.IsZero() {
+		msg.OccurredAt = time.Now().UTC()
+	}
+
+	ctx, cancel := h.withDatabaseTimeout(context.Background())
+	defer cancel()
+
+	if err := h.broadcaster.Publish(ctx, h.q, msg); err != nil {
+		logger.Default.Error(ctx, "failed to publish lobby event", "message_kind", msg.Kind, "error", err)
+
+		// Ao menos os clientes desta instância recebem o evento
+		h.deliver(msg)
+	}
+}
+
+// announceRoomUpdate tells the lobby how an update changed a room: public rooms are updated,
+// rooms made public appear and rooms made unlisted disappear
+func (h apiHandler) announceRoomUpdate(previousVisibility string, room pgstore.Room) {
+	wasPublic := previousVisibility == RoomVisibilityPublic
+	isPublic := room.Visibility == RoomVisibilityPublic
+
+	switch {
+	case wasPublic && isPublic:
+		h.notifyLobby(Message{
+			Kind:  MessageKindRoomUpdated,
+			Value: MessageRoomUpdated{ID: room.ID.String(), Theme: room.Theme, Visibility: room.Visibility},
+		})
+	case isPublic:
+		h.notifyLobby(Message{
+			Kind:  MessageKindRoomCreated,
+			Value: MessageRoomCreated{ID: room.ID.String(), Theme: room.Theme},
+		})
+	case wasPublic:
+		h.notifyLobby(Message{
+			Kind:  MessageKindRoomDeleted,
+			Value: MessageRoomDeleted{ID: room.ID.String(), Reason: "unlisted"},
+		})
+	}
+}
+
+// handleSubscribeLobby serves /subscribe/lobby: a WebSocket connection following the lobby feed
+func (h apiHandler) handleSubscribeLobby(w http.ResponseWriter, r *http.Request) {
+	ctx := WithClientIP(h.userSessionContext(r), clientIP(r))
+
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		logger.Default.Error(r.Context(), "upgrade failed", "error", err)
+		return
+	}
+	defer conn.Close()
+
+	c, err := h.connect(ctx, newWSSubscriber(conn, h.timeouts.WebSocketWrite), config.WebSocketPingInterval)
+	if err != nil {
+		h.serveClient(ctx, c)
+		return
+	}
+	count, _ := h.hub.joinLobby(c)
+
+	logger.Default.Info(ctx, "lobby client connected", "client_ip", r.RemoteAddr, "total_subscribers", count)
+
+	h.serveWebSocket(ctx, conn, newWSConnection(c))
+
+	logger.Default.Info(context.Background(), "lobby client disconnected", "client_ip", r.RemoteAddr, "remaining_subscribers", h.hub.lobbyCount())
+}
+
+// handleLobbyEvents streams the lobby feed as Server-Sent Events
+func (h apiHandler) handleLobbyEvents(w http.ResponseWriter, r *http.Request) {
+	version, err := parseProtocolVersion(r.URL.Query().Get("version"))
+	if err != nil {
+		http.Error(w, "unsupported protocol version", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(WithClientIP(r.Context(), clientIP(r)))
+	defer cancel()
+
+	// Sem quadro de fechamento no SSE: acima dos limites a requisição é recusada antes do stream
+	if err := h.hub.admit(identityFromContext(ctx), ""); err != nil {
+		logger.Default.Warn(ctx, "lobby event stream rejected", "reason", err.Error())
+		rejectStream(w, err)
+		return
+	}
+
+	sub, err := h.openEventStream(w, version)
+	if err != nil {
+		logger.Default.Debug(r.Context(), "failed to start lobby event stream", "error", err)
+		return
+	}
+
+	c, err := h.connect(ctx, sub, sseHeartbeatInterval)
+	if err != nil {
+		h.serveClient(ctx, c)
+		return
+	}
+	count, _ := h.hub.joinLobby(c)
+
+	logger.Default.Info(ctx, "lobby SSE client connected", "client_ip", r.RemoteAddr, "total_subscribers", count)
+
+	h.serveClient(ctx, c)
+
+	logger.Default.Info(context.Background(), "lobby SSE client disconnected", "client_ip", r.RemoteAddr, "remaining_subscribers", h.hub.lobbyCount())
+}
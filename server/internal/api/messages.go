@@ -1,6 +1,8 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"net/http"
 
 	"github.com/JeanGrijp/ask-me-anything/internal/logger"
@@ -10,6 +12,8 @@ import (
 	"github.com/google/uuid"
 )
 
+var errSessionRequired = errors.New("session required")
+
 func (h apiHandler) handleReactToMessage(w http.ResponseWriter, r *http.Request) {
 	_, rawRoomID, roomID, ok := h.readRoom(w, r)
 	if !ok {
@@ -26,28 +30,12 @@ func (h apiHandler) handleReactToMessage(w http.ResponseWriter, r *http.Request)
 
 	logger.Default.Debug(r.Context(), "adding reaction to message", "room_id", rawRoomID, "message_id", rawID)
 
-	// Get user session for tracking reactions
-	session, hasSession := middleware.GetUserSessionFromContext(r.Context())
-	if !hasSession {
+	count, err := h.reactToMessage(r.Context(), rawRoomID, roomID, id)
+	if errors.Is(err, errSessionRequired) {
 		logger.Default.Warn(r.Context(), "no user session found for reaction", "room_id", rawRoomID, "message_id", rawID)
 		http.Error(w, "session required", http.StatusUnauthorized)
 		return
 	}
-
-	// Add user reaction to tracking table
-	err = h.q.AddUserReaction(r.Context(), pgstore.AddUserReactionParams{
-		SessionID:    session.ID,
-		RoomID:       roomID,
-		MessageID:    id,
-		ReactionType: "like", // For now, we only support "like" reactions
-	})
-	if err != nil {
-		logger.Default.Error(r.Context(), "failed to add user reaction", "error", err)
-		// Continue anyway - this is not critical for the reaction count
-	}
-
-	// Increment reaction count
-	count, err := h.q.ReactToMessage(r.Context(), id)
 	if err != nil {
 		http.Error(w, "something went wrong", http.StatusInternalServerError)
 		logger.Default.Error(r.Context(), "failed to react to message", "error", err)
@@ -61,15 +49,45 @@ func (h apiHandler) handleReactToMessage(w http.ResponseWriter, r *http.Request)
 	}
 
 	sendJSON(w, response{Count: count})
+}
 
-	go h.notifyClients(Message{
-		Kind:   MessageKindMessageRactionIncreased,
+// reactToMessage records the reaction of the current session and notifies the room.
+// It is shared by the REST handler and the WebSocket react command.
+func (h apiHandler) reactToMessage(ctx context.Context, rawRoomID string, roomID, messageID uuid.UUID) (int64, error) {
+	// Get user session for tracking reactions
+	session, hasSession := middleware.GetUserSessionFromContext(ctx)
+	if !hasSession {
+		return 0, errSessionRequired
+	}
+
+	// Add user reaction to tracking table
+	err := h.q.AddUserReaction(ctx, pgstore.AddUserReactionParams{
+		SessionID:    session.ID,
+		RoomID:       roomID,
+		MessageID:    messageID,
+		ReactionType: "like", // For now, we only support "like" reactions
+	})
+	if err != nil {
+		logger.Default.Error(ctx, "failed to add user reaction", "error", err)
+		// Continue anyway - this is not critical for the reaction count
+	}
+
+	// Increment reaction count
+	count, err := h.q.ReactToMessage(ctx, messageID)
+	if err != nil {
+		return 0, err
+	}
+
+	h.reactions.increased(messageID.String(), Message{
+		Kind:   MessageKindMessageReactionIncreased,
 		RoomID: rawRoomID,
 		Value: MessageMessageReactionIncreased{
-			ID:    rawID,
+			ID:    messageID.String(),
 			Count: count,
 		},
 	})
+
+	return count, nil
 }
 
 func (h apiHandler) handleRemoveReactFromMessage(w http.ResponseWriter, r *http.Request) {
@@ -88,7 +106,7 @@ func (h apiHandler) handleRemoveReactFromMessage(w http.ResponseWriter, r *http.
 
 	logger.Default.Debug(r.Context(), "removing reaction from message", "room_id", rawRoomID, "message_id", rawID)
 
-	count, err := h.q.RemoveReactionFromMessage(r.Context(), id)
+	count, err := h.removeReaction(r.Context(), rawRoomID, id)
 	if err != nil {
 		http.Error(w, "something went wrong", http.StatusInternalServerError)
 		logger.Default.Error(r.Context(), "failed to remove reaction from message", "room_id", rawRoomID, "message_id", rawID, "error", err)
@@ -102,15 +120,27 @@ func (h apiHandler) handleRemoveReactFromMessage(w http.ResponseWriter, r *http.
 	}
 
 	sendJSON(w, response{Count: count})
+}
 
-	go h.notifyClients(Message{
-		Kind:   MessageKindMessageRactionDecreased,
+// removeReaction decrements the reaction count of a message and notifies the room.
+// It is shared by the REST handler and the WebSocket unreact command.
+func (h apiHandler) removeReaction(ctx context.Context, rawRoomID string, messageID uuid.UUID) (int64, error) {
+	count, err := h.q.RemoveReactionFromMessage(ctx, messageID)
+	if err != nil {
+		return 0, err
+	}
+
+	// Um aumento pendente, ou ainda sendo enviado, sai antes: a contagem final é a desta remoção
+	go h.reactions.decreased(messageID.String(), Message{
+		Kind:   MessageKindMessageReactionDecreased,
 		RoomID: rawRoomID,
 		Value: MessageMessageReactionDecreased{
-			ID:    rawID,
+			ID:    messageID.String(),
 			Count: count,
 		},
 	})
+
+	return count, nil
 }
 
 func (h apiHandler) handleMarkMessageAsAnswered(w http.ResponseWriter, r *http.Request) {
@@ -147,4 +177,7 @@ func (h apiHandler) handleMarkMessageAsAnswered(w http.ResponseWriter, r *http.R
 			ID: rawID,
 		},
 	})
+
+	go h.notifyQuestionAnswered(id)
+	go h.notifyFollowers(id, MessageKindFollowedQuestionAnswered)
 }
@@ -0,0 +1,209 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/JeanGrijp/ask-me-anything/internal/logger"
+	"github.com/JeanGrijp/ask-me-anything/internal/middleware"
+	"github.com/JeanGrijp/ask-me-anything/internal/responses"
+	"github.com/JeanGrijp/ask-me-anything/internal/store/pgstore"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	MessageKindYourQuestionAnswered = "your_question_answered"
+
+	defaultNotificationsLimit = 50
+	maxNotificationsLimit     = 100
+)
+
+// MessageYourQuestionAnswered tells the author of a question that the host answered it.
+// It is a personal event: it has no room of its own and reaches every connection of the
+// author's session, whatever rooms they are watching.
+type MessageYourQuestionAnswered struct {
+	NotificationID string `json:"notification_id"`
+	RoomID         string `json:"room_id"`
+	MessageID      string `json:"message_id"`
+	Message        string `json:"message"`
+}
+
+// NotificationResponse is an entry of the notifications inbox of a session
+type NotificationResponse struct {
+	ID        string `json:"id"`
+	Kind      string `json:"kind"`
+	RoomID    string `json:"room_id"`
+	RoomTheme string `json:"room_theme"`
+	MessageID string `json:"message_id"`
+	Message   string `json:"message"`
+	Read      bool   `json:"read"`
+	ReadAt    string `json:"read_at,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+type NotificationsResponse struct {
+	Notifications []NotificationResponse `json:"notifications"`
+	Unread        int64                  `json:"unread"`
+}
+
+// notifyQuestionAnswered stores a notification for the author of an answered question and
+// delivers it live to the author's session. Anonymous questions and questions already
+// notified are skipped.
+func (h apiHandler) notifyQuestionAnswered(messageID uuid.UUID) {
+	ctx, cancel := h.withDatabaseTimeout(context.Background())
+	defer cancel()
+
+	notification, err := h.q.InsertAnswerNotification(ctx, pgstore.InsertAnswerNotificationParams{
+		ID:   messageID,
+		Kind: MessageKindYourQuestionAnswered,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return
+	}
+	if err != nil {
+		logger.Default.Error(ctx, "failed to store answer notification", "message_id", messageID.String(), "error", err)
+		return
+	}
+
+	h.notifySessions(ctx, Message{
+		Kind: MessageKindYourQuestionAnswered,
+		Value: MessageYourQuestionAnswered{
+			NotificationID: notification.ID.String(),
+			RoomID:         notification.RoomID.String(),
+			MessageID:      notification.MessageID.String(),
+			Message:        notification.Message,
+		},
+		Audience:   Audience{SessionIDs: []string{notification.SessionID.String()}},
+		OccurredAt: notification.CreatedAt.Time,
+	})
+}
+
+// notifySessions publishes a personal event, addressed to the sessions of its audience and
+// not to a room, to every instance. Personal events have no sequence number: sessions that
+// are offline find them in their notifications inbox.
+func (h apiHandler) notifySessions(ctx context.Context, msg Message) {
+	err := h.broadcaster.Publish(ctx, h.q, msg)
+	if err != nil {
+		logger.Default.Error(ctx, "failed to publish personal event", "message_kind", msg.Kind, "error", err)
+
+		// Ao menos as conexões desta instância recebem o evento
+		h.deliver(msg)
+	}
+}
+
+// handleGetNotifications returns the notifications inbox of the current session, newest
+// first. ?unread=true lists only unread notifications.
+func (h apiHandler) handleGetNotifications(w http.ResponseWriter, r *http.Request) {
+	session, ok := middleware.GetUserSessionFromContext(r.Context())
+	if !ok {
+		responses.SendError(w, http.StatusUnauthorized, "No active session")
+		return
+	}
+
+	limit := defaultNotificationsLimit
+	if rawLimit := r.URL.Query().Get("limit"); rawLimit != "" {
+		parsed, err := strconv.Atoi(rawLimit)
+		if err != nil || parsed < 1 || parsed > maxNotificationsLimit {
+			responses.SendError(w, http.StatusBadRequest, "Limit must be between 1 and 100")
+			return
+		}
+		limit = parsed
+	}
+
+	unreadOnly, _ := strconv.ParseBool(r.URL.Query().Get("unread"))
+
+	notifications, err := h.q.GetSessionNotifications(r.Context(), pgstore.GetSessionNotificationsParams{
+		SessionID:  session.ID,
+		UnreadOnly: unreadOnly,
+		MaxCount:   int32(limit),
+	})
+	if err != nil {
+		logger.Default.Error(r.Context(), "failed to get notifications", "error", err)
+		responses.SendError(w, http.StatusInternalServerError, "Failed to get notifications")
+		return
+	}
+
+	unread, err := h.q.CountUnreadNotifications(r.Context(), session.ID)
+	if err != nil {
+		logger.Default.Error(r.Context(), "failed to count unread notifications", "error", err)
+		responses.SendError(w, http.StatusInternalServerError, "Failed to get notifications")
+		return
+	}
+
+	response := NotificationsResponse{
+		Notifications: make([]NotificationResponse, 0, len(notifications)),
+		Unread:        unread,
+	}
+	for _, n := range notifications {
+		response.Notifications = append(response.Notifications, NotificationResponse{
+			ID:        n.ID.String(),
+			Kind:      n.Kind,
+			RoomID:    n.RoomID.String(),
+			RoomTheme: n.Theme,
+			MessageID: n.MessageID.String(),
+			Message:   n.Message,
+			Read:      n.ReadAt.Valid,
+			ReadAt:    formatExportTime(n.ReadAt),
+			CreatedAt: formatExportTime(n.CreatedAt),
+		})
+	}
+
+	sendJSON(w, response)
+}
+
+// handleMarkNotificationRead marks one notification of the current session as read
+func (h apiHandler) handleMarkNotificationRead(w http.ResponseWriter, r *http.Request) {
+	session, ok := middleware.GetUserSessionFromContext(r.Context())
+	if !ok {
+		responses.SendError(w, http.StatusUnauthorized, "No active session")
+		return
+	}
+
+	notificationID, err := uuid.Parse(chi.URLParam(r, "notification_id"))
+	if err != nil {
+		responses.SendError(w, http.StatusBadRequest, "Invalid notification id")
+		return
+	}
+
+	updated, err := h.q.MarkNotificationRead(r.Context(), pgstore.MarkNotificationReadParams{
+		ID:        notificationID,
+		SessionID: session.ID,
+	})
+	if err != nil {
+		logger.Default.Error(r.Context(), "failed to mark notification as read", "notification_id", notificationID.String(), "error", err)
+		responses.SendError(w, http.StatusInternalServerError, "Failed to mark notification as read")
+		return
+	}
+
+	// Notificações de outras sessões não são reveladas
+	if updated == 0 {
+		responses.SendError(w, http.StatusNotFound, "Notification not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleMarkAllNotificationsRead marks every notification of the current session as read
+func (h apiHandler) handleMarkAllNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	session, ok := middleware.GetUserSessionFromContext(r.Context())
+	if !ok {
+		responses.SendError(w, http.StatusUnauthorized, "No active session")
+		return
+	}
+
+	updated, err := h.q.MarkAllNotificationsRead(r.Context(), session.ID)
+	if err != nil {
+		logger.Default.Error(r.Context(), "failed to mark notifications as read", "error", err)
+		responses.SendError(w, http.StatusInternalServerError, "Failed to mark notifications as read")
+		return
+	}
+
+	logger.Default.Debug(r.Context(), "notifications marked as read", "count", updated)
+
+	w.WriteHeader(http.StatusNoContent)
+}
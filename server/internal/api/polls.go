@@ -0,0 +1,465 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/JeanGrijp/ask-me-anything/internal/logger"
+	"github.com/JeanGrijp/ask-me-anything/internal/middleware"
+	"github.com/JeanGrijp/ask-me-anything/internal/responses"
+	"github.com/JeanGrijp/ask-me-anything/internal/store/pgstore"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	PollStatusDraft  = "draft"
+	PollStatusOpen   = "open"
+	PollStatusClosed = "closed"
+
+	minPollOptions        = 2
+	maxPollOptions        = 10
+	maxPollOptionLength   = 100
+	maxPollQuestionLength = 255
+)
+
+var (
+	errAlreadyVoted = errors.New("session has already voted in this poll")
+	errPollNotOpen  = errors.New("poll is not open for voting")
+)
+
+// PollOptionResult represents one option of a poll with its current tally
+type PollOptionResult struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+	Votes int64  `json:"votes"`
+}
+
+// PollResponse represents a poll with its options and live results
+type PollResponse struct {
+	ID            string             `json:"id"`
+	RoomID        string             `json:"room_id"`
+	Question      string             `json:"question"`
+	Status        string             `json:"status"`
+	Options       []PollOptionResult `json:"options"`
+	TotalVotes    int64              `json:"total_votes"`
+	VotedOptionID string             `json:"voted_option_id,omitempty"`
+}
+
+// handleCreatePoll creates a new poll in draft state (host only)
+func (h apiHandler) handleCreatePoll(w http.ResponseWriter, r *http.Request) {
+	_, rawRoomID, roomID, ok := h.readRoom(w, r)
+	if !ok {
+		return
+	}
+
+	type _body struct {
+		Question string   `json:"question"`
+		Options  []string `json:"options"`
+	}
+	var body _body
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		logger.Default.Warn(r.Context(), "invalid JSON in create poll request", "error", err)
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+
+	body.Question = strings.TrimSpace(body.Question)
+	if body.Question == "" || len(body.Question) > maxPollQuestionLength {
+		http.Error(w, "poll question is required and must have at most 255 characters", http.StatusBadRequest)
+		return
+	}
+
+	if len(body.Options) < minPollOptions || len(body.Options) > maxPollOptions {
+		http.Error(w, "polls must have between 2 and 10 options", http.StatusBadRequest)
+		return
+	}
+
+	for i, option := range body.Options {
+		body.Options[i] = strings.TrimSpace(option)
+		if body.Options[i] == "" || len(body.Options[i]) > maxPollOptionLength {
+			http.Error(w, "poll options must not be empty and must have at most 100 characters", http.StatusBadRequest)
+			return
+		}
+	}
+
+	var poll pgstore.Poll
+	err := h.withTx(r.Context(), func(q *pgstore.Queries) error {
+		var err error
+		poll, err = q.InsertPoll(r.Context(), pgstore.InsertPollParams{
+			RoomID:   roomID,
+			Question: body.Question,
+		})
+		if err != nil {
+			return err
+		}
+
+		return q.InsertPollOptions(r.Context(), pgstore.InsertPollOptionsParams{
+			PollID: poll.ID,
+			Labels: body.Options,
+		})
+	})
+	if err != nil {
+		logger.Default.Error(r.Context(), "failed to create poll", "room_id", rawRoomID, "error", err)
+		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		return
+	}
+
+	logger.Default.Info(r.Context(), "poll created successfully", "room_id", rawRoomID, "poll_id", poll.ID.String())
+
+	response, err := h.buildPollResponse(r.Context(), poll)
+	if err != nil {
+		logger.Default.Error(r.Context(), "failed to get poll results", "poll_id", poll.ID.String(), "error", err)
+		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		return
+	}
+
+	responses.JSON(w, http.StatusCreated, response)
+}
+
+// handleGetRoomPolls returns every poll of a room with its current results
+func (h apiHandler) handleGetRoomPolls(w http.ResponseWriter, r *http.Request) {
+	_, rawRoomID, roomID, ok := h.readRoom(w, r)
+	if !ok {
+		return
+	}
+
+	polls, err := h.q.GetRoomPolls(r.Context(), roomID)
+	if err != nil {
+		logger.Default.Error(r.Context(), "failed to get room polls", "room_id", rawRoomID, "error", err)
+		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		return
+	}
+
+	result := make([]PollResponse, 0, len(polls))
+	for _, poll := range polls {
+		response, err := h.buildPollResponse(r.Context(), poll)
+		if err != nil {
+			logger.Default.Error(r.Context(), "failed to get poll results", "poll_id", poll.ID.String(), "error", err)
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+		result = append(result, h.withSessionVote(r, response))
+	}
+
+	sendJSON(w, result)
+}
+
+// handleGetPoll returns a single poll with its current results
+func (h apiHandler) handleGetPoll(w http.ResponseWriter, r *http.Request) {
+	_, _, roomID, ok := h.readRoom(w, r)
+	if !ok {
+		return
+	}
+
+	poll, ok := h.readPoll(w, r, roomID)
+	if !ok {
+		return
+	}
+
+	response, err := h.buildPollResponse(r.Context(), poll)
+	if err != nil {
+		logger.Default.Error(r.Context(), "failed to get poll results", "poll_id", poll.ID.String(), "error", err)
+		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSON(w, h.withSessionVote(r, response))
+}
+
+// handleOpenPoll opens a draft poll for voting (host only)
+func (h apiHandler) handleOpenPoll(w http.ResponseWriter, r *http.Request) {
+	_, rawRoomID, roomID, ok := h.readRoom(w, r)
+	if !ok {
+		return
+	}
+
+	poll, ok := h.readPoll(w, r, roomID)
+	if !ok {
+		return
+	}
+
+	updated, err := h.q.OpenPoll(r.Context(), pgstore.OpenPollParams{ID: poll.ID, RoomID: roomID})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "only draft polls can be opened", http.StatusConflict)
+			return
+		}
+
+		logger.Default.Error(r.Context(), "failed to open poll", "room_id", rawRoomID, "poll_id", poll.ID.String(), "error", err)
+		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		return
+	}
+
+	response, err := h.buildPollResponse(r.Context(), updated)
+	if err != nil {
+		logger.Default.Error(r.Context(), "failed to get poll results", "poll_id", poll.ID.String(), "error", err)
+		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		return
+	}
+
+	logger.Default.Info(r.Context(), "poll opened", "room_id", rawRoomID, "poll_id", response.ID)
+
+	sendJSON(w, response)
+
+	go h.notifyClients(Message{
+		Kind:   MessageKindPollOpened,
+		RoomID: rawRoomID,
+		Value: MessagePollOpened{
+			ID:       response.ID,
+			Question: response.Question,
+			Options:  response.Options,
+		},
+	})
+}
+
+// handleClosePoll closes an open poll and publishes the final results (host only)
+func (h apiHandler) handleClosePoll(w http.ResponseWriter, r *http.Request) {
+	_, rawRoomID, roomID, ok := h.readRoom(w, r)
+	if !ok {
+		return
+	}
+
+	poll, ok := h.readPoll(w, r, roomID)
+	if !ok {
+		return
+	}
+
+	updated, err := h.q.ClosePoll(r.Context(), pgstore.ClosePollParams{ID: poll.ID, RoomID: roomID})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "only open polls can be closed", http.StatusConflict)
+			return
+		}
+
+		logger.Default.Error(r.Context(), "failed to close poll", "room_id", rawRoomID, "poll_id", poll.ID.String(), "error", err)
+		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		return
+	}
+
+	response, err := h.buildPollResponse(r.Context(), updated)
+	if err != nil {
+		logger.Default.Error(r.Context(), "failed to get poll results", "poll_id", poll.ID.String(), "error", err)
+		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		return
+	}
+
+	logger.Default.Info(r.Context(), "poll closed", "room_id", rawRoomID, "poll_id", response.ID, "total_votes", response.TotalVotes)
+
+	sendJSON(w, response)
+
+	go h.notifyClients(Message{
+		Kind:   MessageKindPollClosed,
+		RoomID: rawRoomID,
+		Value: MessagePollClosed{
+			ID:         response.ID,
+			Options:    response.Options,
+			TotalVotes: response.TotalVotes,
+		},
+	})
+}
+
+// handleVotePoll registers the vote of the current session on an open poll
+func (h apiHandler) handleVotePoll(w http.ResponseWriter, r *http.Request) {
+	_, rawRoomID, roomID, ok := h.readRoom(w, r)
+	if !ok {
+		return
+	}
+
+	session, hasSession := middleware.GetUserSessionFromContext(r.Context())
+	if !hasSession {
+		logger.Default.Warn(r.Context(), "no user session found for poll vote", "room_id", rawRoomID)
+		http.Error(w, "session required", http.StatusUnauthorized)
+		return
+	}
+
+	poll, ok := h.readPoll(w, r, roomID)
+	if !ok {
+		return
+	}
+
+	type _body struct {
+		OptionID string `json:"option_id"`
+	}
+	var body _body
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		logger.Default.Warn(r.Context(), "invalid JSON in poll vote request", "error", err)
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+
+	optionID, err := uuid.Parse(body.OptionID)
+	if err != nil {
+		http.Error(w, "invalid option id", http.StatusBadRequest)
+		return
+	}
+
+	if poll.Status != PollStatusOpen {
+		http.Error(w, "poll is not open for voting", http.StatusConflict)
+		return
+	}
+
+	before, err := h.buildPollResponse(r.Context(), poll)
+	if err != nil {
+		logger.Default.Error(r.Context(), "failed to get poll results", "poll_id", poll.ID.String(), "error", err)
+		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		return
+	}
+
+	if !pollHasOption(before, optionID.String()) {
+		http.Error(w, "option does not belong to this poll", http.StatusBadRequest)
+		return
+	}
+
+	// O voto, a contagem e o evento ficam na mesma transação. O voto trava a enquete até o
+	// commit, então cada contagem já inclui os votos anteriores e sai com um número de
+	// sequência maior: os clientes nunca recebem um resultado mais antigo depois de um novo.
+	var response PollResponse
+	msg, committed, err := h.publishRoomEvent(r.Context(), rawRoomID, func(q *pgstore.Queries) (Message, error) {
+		rowsAffected, err := q.InsertPollVote(r.Context(), pgstore.InsertPollVoteParams{
+			PollID:    poll.ID,
+			OptionID:  optionID,
+			SessionID: session.ID,
+		})
+		if err != nil {
+			return Message{}, err
+		}
+
+		if rowsAffected == 0 {
+			_, err := q.GetSessionPollVote(r.Context(), pgstore.GetSessionPollVoteParams{PollID: poll.ID, SessionID: session.ID})
+			if err == nil {
+				return Message{}, errAlreadyVoted
+			}
+			return Message{}, errPollNotOpen
+		}
+
+		if response, err = pollResponse(r.Context(), q, poll); err != nil {
+			return Message{}, err
+		}
+
+		return Message{
+			Kind:   MessageKindPollResultsUpdated,
+			RoomID: rawRoomID,
+			Value: MessagePollResultsUpdated{
+				ID:         response.ID,
+				Options:    response.Options,
+				TotalVotes: response.TotalVotes,
+			},
+		}, nil
+	})
+
+	switch {
+	case errors.Is(err, errAlreadyVoted):
+		http.Error(w, "you have already voted in this poll", http.StatusConflict)
+		return
+	case errors.Is(err, errPollNotOpen):
+		http.Error(w, "poll is not open for voting", http.StatusConflict)
+		return
+	case err != nil && !committed:
+		logger.Default.Error(r.Context(), "failed to insert poll vote", "poll_id", poll.ID.String(), "error", err)
+		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		return
+	case err != nil:
+		// O voto foi gravado; os clientes recebem a contagem ao recuperar o log da sala
+		logger.Default.Error(r.Context(), "failed to publish poll results", "poll_id", poll.ID.String(), "error", err)
+	}
+	response.VotedOptionID = optionID.String()
+
+	logger.Default.Info(r.Context(), "poll vote registered", "room_id", rawRoomID, "poll_id", response.ID, "total_votes", response.TotalVotes, "seq", msg.Seq)
+
+	sendJSON(w, response)
+
+	go h.enqueueWebhooks(msg)
+}
+
+// readPoll loads the poll from the URL, writing the error response on failure
+func (h apiHandler) readPoll(w http.ResponseWriter, r *http.Request, roomID uuid.UUID) (pgstore.Poll, bool) {
+	rawPollID := chi.URLParam(r, "poll_id")
+	pollID, err := uuid.Parse(rawPollID)
+	if err != nil {
+		http.Error(w, "invalid poll id", http.StatusBadRequest)
+		return pgstore.Poll{}, false
+	}
+
+	poll, err := h.q.GetPoll(r.Context(), pgstore.GetPollParams{ID: pollID, RoomID: roomID})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "poll not found", http.StatusNotFound)
+			return pgstore.Poll{}, false
+		}
+
+		logger.Default.Error(r.Context(), "failed to get poll", "poll_id", rawPollID, "error", err)
+		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		return pgstore.Poll{}, false
+	}
+
+	return poll, true
+}
+
+// buildPollResponse combines a poll with its current tallies
+func (h apiHandler) buildPollResponse(ctx context.Context, poll pgstore.Poll) (PollResponse, error) {
+	return pollResponse(ctx, h.q, poll)
+}
+
+// pollResponse combines a poll with its tallies as read through q, which may be bound to a transaction
+func pollResponse(ctx context.Context, q *pgstore.Queries, poll pgstore.Poll) (PollResponse, error) {
+	results, err := q.GetPollResults(ctx, poll.ID)
+	if err != nil {
+		return PollResponse{}, err
+	}
+
+	response := PollResponse{
+		ID:       poll.ID.String(),
+		RoomID:   poll.RoomID.String(),
+		Question: poll.Question,
+		Status:   poll.Status,
+		Options:  make([]PollOptionResult, 0, len(results)),
+	}
+
+	for _, result := range results {
+		response.Options = append(response.Options, PollOptionResult{
+			ID:    result.ID.String(),
+			Label: result.Label,
+			Votes: result.Votes,
+		})
+		response.TotalVotes += result.Votes
+	}
+
+	return response, nil
+}
+
+// withSessionVote fills the option the current session voted for, if any
+func (h apiHandler) withSessionVote(r *http.Request, response PollResponse) PollResponse {
+	session, ok := middleware.GetUserSessionFromContext(r.Context())
+	if !ok {
+		return response
+	}
+
+	pollID, err := uuid.Parse(response.ID)
+	if err != nil {
+		return response
+	}
+
+	optionID, err := h.q.GetSessionPollVote(r.Context(), pgstore.GetSessionPollVoteParams{
+		PollID:    pollID,
+		SessionID: session.ID,
+	})
+	if err == nil {
+		response.VotedOptionID = optionID.String()
+	}
+
+	return response
+}
+
+func pollHasOption(poll PollResponse, optionID string) bool {
+	for _, option := range poll.Options {
+		if option.ID == optionID {
+			return true
+		}
+	}
+	return false
+}
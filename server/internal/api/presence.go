@@ -0,0 +1,375 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/JeanGrijp/ask-me-anything/internal/logger"
+)
+
+const (
+	// Intervalo mínimo entre dois presence_updated da mesma sala
+	presenceUpdateInterval = 2 * time.Second
+	// Cada instância reenvia as contagens das suas salas nesse intervalo
+	presenceHeartbeatInterval = 15 * time.Second
+	// Contagem não renovada nesse prazo é de uma instância que parou
+	presenceCountTTL = 3 * presenceHeartbeatInterval
+)
+
+// MessageKindPresenceCount carries the viewers of a room at one instance to the other
+// instances; it is never sent to clients
+const MessageKindPresenceCount = "presence_count"
+
+// MessagePresenceUpdated announces the viewers of a room across every instance
+type MessagePresenceUpdated struct {
+	Viewers int `json:"viewers"`
+}
+
+type MessagePresenceCount struct {
+	Instance string `json:"instance"`
+	Viewers  int    `json:"viewers"`
+}
+
+// PresenceSession is a user session connected to a room, shown to the host
+type PresenceSession struct {
+	SessionID   string    `json:"session_id"`
+	DisplayName *string   `json:"display_name"`
+	Connections int       `json:"connections"`
+	ConnectedAt time.Time `json:"connected_at"`
+}
+
+// PresenceResponse details who is connected to a room through this server instance
+type PresenceResponse struct {
+	RoomID    string            `json:"room_id"`
+	Viewers   int               `json:"viewers"`
+	Anonymous int               `json:"anonymous"`
+	Sessions  []PresenceSession `json:"sessions"`
+}
+
+// presenceTracker counts the viewers of each room connected to this instance. Connections of
+// the same user session (several tabs, WebSocket and SSE) count once; connections without a
+// session count individually. Every instance publishes its counts to the others through the
+// broadcaster, and the viewers of a room are the sum of them; a session connected through two
+// instances counts twice. Changes are announced with a throttled presence_updated event.
+type presenceTracker struct {
+	mu       sync.Mutex
+	rooms    map[string]*roomPresence
+	interval time.Duration
+	// instance tells the counts of this instance apart from the ones of the others
+	instance string
+	deliver  func(Message)
+	publish  func(Message)
+}
+
+type roomPresence struct {
+	sessions  map[string]*PresenceSession
+	anonymous int
+	// remote are the viewers of the room at the other instances
+	remote map[string]remoteViewers
+	// Estado do throttle: um único envio pendente por sala
+	pending   bool
+	lastSent  time.Time
+	lastCount int
+	// lastPublished é a contagem local enviada às outras instâncias
+	lastPublished int
+}
+
+type remoteViewers struct {
+	viewers int
+	seenAt  time.Time
+}
+
+// newPresenceTracker returns a tracker that sends presence events to the clients of this
+// instance with deliver and its counts to the other instances with publish
+func newPresenceTracker(instance string, deliver, publish func(Message)) *presenceTracker {
+	return &presenceTracker{
+		rooms:    make(map[string]*roomPresence),
+		interval: presenceUpdateInterval,
+		instance: instance,
+		deliver:  deliver,
+		publish:  publish,
+	}
+}
+
+// viewers returns the viewers of the room connected to this instance
+func (r *roomPresence) viewers() int {
+	return len(r.sessions) + r.anonymous
+}
+
+// total returns the viewers of the room across every instance
+func (r *roomPresence) total() int {
+	total := r.viewers()
+	for _, remote := range r.remote {
+		total += remote.viewers
+	}
+	return total
+}
+
+// room returns the presence of a room, creating it if needed. The caller must hold p.mu.
+func (p *presenceTracker) room(roomID string) *roomPresence {
+	room, ok := p.rooms[roomID]
+	if !ok {
+		room = &roomPresence{
+			sessions: make(map[string]*PresenceSession),
+			remote:   make(map[string]remoteViewers),
+		}
+		p.rooms[roomID] = room
+	}
+	return room
+}
+
+// join registers a connection of c's session in a room
+func (p *presenceTracker) join(roomID string, c *client) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	room := p.room(roomID)
+
+	if c.identity.sessionID == "" {
+		room.anonymous++
+	} else if session, ok := room.sessions[c.identity.sessionID]; ok {
+		session.Connections++
+	} else {
+		session := &PresenceSession{
+			SessionID:   c.identity.sessionID,
+			Connections: 1,
+			ConnectedAt: time.Now().UTC(),
+		}
+		if c.identity.displayName != "" {
+			name := c.identity.displayName
+			session.DisplayName = &name
+		}
+		room.sessions[c.identity.sessionID] = session
+	}
+
+	p.schedule(roomID, room)
+}
+
+// leave removes a connection registered with join
+func (p *presenceTracker) leave(roomID string, c *client) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	room, ok := p.rooms[roomID]
+	if !ok {
+		return
+	}
+
+	if c.identity.sessionID == "" {
+		room.anonymous = max(room.anonymous-1, 0)
+	} else if session, ok := room.sessions[c.identity.sessionID]; ok {
+		session.Connections--
+		if session.Connections <= 0 {
+			delete(room.sessions, c.identity.sessionID)
+		}
+	}
+
+	p.schedule(roomID, room)
+}
+
+// schedule sends presence_updated right away if the room has not sent one in the last
+// interval, or once the interval ends otherwise. The caller must hold p.mu.
+func (p *presenceTracker) schedule(roomID string, room *roomPresence) {
+	if room.pending {
+		return
+	}
+	room.pending = true
+
+	delay := max(time.Until(room.lastSent.Add(p.interval)), 0)
+	time.AfterFunc(delay, func() { p.flush(roomID) })
+}
+
+// flush publishes the count of this instance to the others if it changed, sends the hosts who
+// is connected here, and announces the viewers of every instance to the room if they changed
+func (p *presenceTracker) flush(roomID string) {
+	p.mu.Lock()
+
+	room, ok := p.rooms[roomID]
+	if !ok {
+		p.mu.Unlock()
+		return
+	}
+
+	room.pending = false
+	local := room.viewers()
+	total := room.total()
+
+	publishCount := local != room.lastPublished
+	countChanged := total != room.lastCount
+
+	room.lastPublished = local
+	room.lastSent = time.Now()
+	room.lastCount = total
+
+	// Ninguém conectado aqui: não há clientes para avisar
+	var detail PresenceResponse
+	if local > 0 {
+		detail = room.detail(roomID)
+	} else if len(room.remote) == 0 {
+		delete(p.rooms, roomID)
+	}
+	p.mu.Unlock()
+
+	if publishCount {
+		p.publish(p.countMessage(roomID, local))
+	}
+
+	if local == 0 {
+		return
+	}
+
+	// Presença é efêmera: vai só aos clientes desta instância, sem número de sequência
+	p.deliver(Message{
+		Kind:     MessageKindPresenceDetail,
+		RoomID:   roomID,
+		Value:    detail,
+		Audience: Audience{Hosts: true},
+	})
+
+	if countChanged {
+		p.deliver(Message{
+			Kind:   MessageKindPresenceUpdated,
+			RoomID: roomID,
+			Value:  MessagePresenceUpdated{Viewers: total},
+		})
+	}
+}
+
+func (p *presenceTracker) countMessage(roomID string, viewers int) Message {
+	return Message{
+		Kind:   MessageKindPresenceCount,
+		RoomID: roomID,
+		Value:  MessagePresenceCount{Instance: p.instance, Viewers: viewers},
+	}
+}
+
+// count records the viewers of a room at another instance, received through the broadcaster
+func (p *presenceTracker) count(msg Message) {
+	var value MessagePresenceCount
+	switch v := msg.Value.(type) {
+	case MessagePresenceCount:
+		value = v
+	case json.RawMessage:
+		if err := json.Unmarshal(v, &value); err != nil {
+			return
+		}
+	default:
+		return
+	}
+
+	// O broadcaster também entrega as contagens desta instância
+	if value.Instance == p.instance {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	room := p.room(msg.RoomID)
+	if value.Viewers > 0 {
+		room.remote[value.Instance] = remoteViewers{viewers: value.Viewers, seenAt: time.Now()}
+	} else {
+		delete(room.remote, value.Instance)
+	}
+
+	p.schedule(msg.RoomID, room)
+}
+
+// run publishes the counts of this instance again every presenceHeartbeatInterval, so
+// instances started later learn them, and drops the counts of instances that stopped
+// publishing theirs, until ctx is done
+func (p *presenceTracker) run(ctx context.Context) {
+	ticker := time.NewTicker(presenceHeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.heartbeat(time.Now())
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (p *presenceTracker) heartbeat(now time.Time) {
+	var counts []Message
+
+	p.mu.Lock()
+	for roomID, room := range p.rooms {
+		if local := room.viewers(); local > 0 {
+			counts = append(counts, p.countMessage(roomID, local))
+		}
+
+		for instance, remote := range room.remote {
+			if now.Sub(remote.seenAt) > presenceCountTTL {
+				delete(room.remote, instance)
+				p.schedule(roomID, room)
+			}
+		}
+	}
+	p.mu.Unlock()
+
+	for _, msg := range counts {
+		p.publish(msg)
+	}
+}
+
+// viewers returns the number of distinct viewers of the room across every instance
+func (p *presenceTracker) viewers(roomID string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	room, ok := p.rooms[roomID]
+	if !ok {
+		return 0
+	}
+	return room.total()
+}
+
+// detail returns who is connected to the room, oldest sessions first
+func (p *presenceTracker) detail(roomID string) PresenceResponse {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	room, ok := p.rooms[roomID]
+	if !ok {
+		return PresenceResponse{RoomID: roomID, Sessions: []PresenceSession{}}
+	}
+
+	return room.detail(roomID)
+}
+
+func (r *roomPresence) detail(roomID string) PresenceResponse {
+	response := PresenceResponse{RoomID: roomID, Sessions: []PresenceSession{}}
+
+	response.Viewers = r.viewers()
+	response.Anonymous = r.anonymous
+	for _, session := range r.sessions {
+		response.Sessions = append(response.Sessions, *session)
+	}
+	sort.Slice(response.Sessions, func(i, j int) bool {
+		return response.Sessions[i].ConnectedAt.Before(response.Sessions[j].ConnectedAt)
+	})
+
+	return response
+}
+
+// handleGetRoomPresence lists the sessions connected to a room (host only).
+// Counts cover the connections held by this server instance.
+func (h apiHandler) handleGetRoomPresence(w http.ResponseWriter, r *http.Request) {
+	_, rawRoomID, _, ok := h.readRoom(w, r)
+	if !ok {
+		return
+	}
+
+	response := h.presence.detail(rawRoomID)
+
+	logger.Default.Debug(r.Context(), "room presence fetched", "room_id", rawRoomID, "viewers", response.Viewers)
+
+	sendJSON(w, response)
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// recordedMessages collects the messages a presence tracker delivers or publishes
+type recordedMessages struct {
+	mu   sync.Mutex
+	msgs []Message
+}
+
+func (r *recordedMessages) add(msg Message) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.msgs = append(r.msgs, msg)
+}
+
+func (r *recordedMessages) last(kind string) (Message, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := len(r.msgs) - 1; i >= 0; i-- {
+		if r.msgs[i].Kind == kind {
+			return r.msgs[i], true
+		}
+	}
+	return Message{}, false
+}
+
+func newTestPresence() (*presenceTracker, *recordedMessages, *recordedMessages) {
+	delivered, published := &recordedMessages{}, &recordedMessages{}
+	p := newPresenceTracker(uuid.NewString(), delivered.add, published.add)
+	p.interval = 0
+	return p, delivered, published
+}
+
+func remoteCount(roomID, instance string, viewers int) Message {
+	value, _ := json.Marshal(MessagePresenceCount{Instance: instance, Viewers: viewers})
+	// Pelo broadcaster do Postgres o valor chega como JSON
+	return Message{Kind: MessageKindPresenceCount, RoomID: roomID, Value: json.RawMessage(value)}
+}
+
+func TestPresenceSumsInstances(t *testing.T) {
+	roomID := uuid.NewString()
+	p, delivered, published := newTestPresence()
+
+	p.join(roomID, newClient(nopSubscriber{}, connIdentity{sessionID: "a"}))
+	p.join(roomID, newClient(nopSubscriber{}, connIdentity{sessionID: "a"}))
+	p.join(roomID, newClient(nopSubscriber{}, connIdentity{}))
+	p.flush(roomID)
+
+	if msg, ok := published.last(MessageKindPresenceCount); !ok || msg.Value.(MessagePresenceCount).Viewers != 2 {
+		t.Fatalf("published %+v, want this instance's 2 viewers", msg)
+	}
+
+	p.count(remoteCount(roomID, "other", 3))
+	// A contagem desta instância que volta pelo broadcaster é ignorada
+	p.count(Message{Kind: MessageKindPresenceCount, RoomID: roomID, Value: MessagePresenceCount{Instance: p.instance, Viewers: 50}})
+	p.flush(roomID)
+
+	if got := p.viewers(roomID); got != 5 {
+		t.Errorf("viewers = %d, want 5", got)
+	}
+	if msg, ok := delivered.last(MessageKindPresenceUpdated); !ok || msg.Value.(MessagePresenceUpdated).Viewers != 5 {
+		t.Errorf("presence_updated = %+v, want 5 viewers", msg)
+	}
+	if msg, _ := delivered.last(MessageKindPresenceDetail); msg.Value.(PresenceResponse).Viewers != 2 {
+		t.Errorf("presence_detail = %+v, want the 2 viewers of this instance", msg.Value)
+	}
+
+	p.count(remoteCount(roomID, "other", 0))
+	if got := p.viewers(roomID); got != 2 {
+		t.Errorf("viewers after the other instance emptied = %d, want 2", got)
+	}
+}
+
+func TestPresenceDropsStaleInstances(t *testing.T) {
+	roomID := uuid.NewString()
+	p, _, published := newTestPresence()
+
+	p.count(remoteCount(roomID, "other", 4))
+	if got := p.viewers(roomID); got != 4 {
+		t.Fatalf("viewers = %d, want 4", got)
+	}
+
+	// Sem conexões aqui, nada é reenviado; a contagem da outra instância ainda vale
+	p.heartbeat(time.Now())
+	if _, ok := published.last(MessageKindPresenceCount); ok {
+		t.Error("heartbeat published a count for a room without local viewers")
+	}
+	if got := p.viewers(roomID); got != 4 {
+		t.Errorf("viewers = %d, want 4 before the count expires", got)
+	}
+
+	p.heartbeat(time.Now().Add(presenceCountTTL + time.Second))
+	if got := p.viewers(roomID); got != 0 {
+		t.Errorf("viewers = %d, want 0 after the count expired", got)
+	}
+}
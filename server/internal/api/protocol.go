@@ -0,0 +1,208 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// protocolVersion is a version of the realtime event format. Clients choose it with the
+// WebSocket subprotocol (or ?version= on the SSE stream); without one they get version 1.
+type protocolVersion int
+
+const (
+	// Versão 1: {kind, value, room_id, seq}, o formato original
+	protocolV1 protocolVersion = iota + 1
+	// Versão 2: envelope com versão, id, sala, horário e sequência
+	protocolV2
+
+	latestProtocol = protocolV2
+)
+
+// wireFormat is how frames are serialized on a connection
+type wireFormat int
+
+const (
+	// Quadros de texto JSON
+	formatJSON wireFormat = iota
+	// Quadros binários MessagePack, com os mesmos campos do envelope JSON
+	formatMsgPack
+)
+
+// Subprotocolos WebSocket aceitos, em ordem de preferência do servidor
+const (
+	SubprotocolV1        = "ama.v1"
+	SubprotocolV2        = "ama.v2"
+	SubprotocolV2MsgPack = "ama.v2.msgpack"
+)
+
+var supportedSubprotocols = []string{SubprotocolV2MsgPack, SubprotocolV2, SubprotocolV1}
+
+// SubprotocolHostTokenPrefix carries the host token in Sec-WebSocket-Protocol, the only header a
+// browser can set on a WebSocket. It is never chosen by the server, so the client must also offer
+// one of the supported subprotocols: ["ama.v2", "ama.host-token.<token>"].
+const SubprotocolHostTokenPrefix = "ama.host-token."
+
+// hostTokenFromSubprotocols returns the host token offered in the handshake subprotocols, if any
+func hostTokenFromSubprotocols(r *http.Request) string {
+	for _, subprotocol := range websocket.Subprotocols(r) {
+		if token, ok := strings.CutPrefix(subprotocol, SubprotocolHostTokenPrefix); ok {
+			return token
+		}
+	}
+	return ""
+}
+
+// protocolFromSubprotocol returns the version and format negotiated in the WebSocket handshake
+func protocolFromSubprotocol(subprotocol string) (protocolVersion, wireFormat) {
+	switch subprotocol {
+	case SubprotocolV2MsgPack:
+		return protocolV2, formatMsgPack
+	case SubprotocolV2:
+		return protocolV2, formatJSON
+	}
+	return protocolV1, formatJSON
+}
+
+// parseProtocolVersion reads ?version= of the SSE stream; an empty value means version 1
+func parseProtocolVersion(raw string) (protocolVersion, error) {
+	switch raw {
+	case "", "1":
+		return protocolV1, nil
+	case "2":
+		return protocolV2, nil
+	}
+	return 0, fmt.Errorf("unsupported protocol version %q", raw)
+}
+
+// Envelope is the version 2 frame of every realtime event. Payload is described, per Type,
+// by the JSON Schema served at /api/schema/events.
+type Envelope struct {
+	Version   int       `json:"version"`
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	RoomID    string    `json:"room_id,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	Seq       int64     `json:"seq,omitempty"`
+	Payload   any       `json:"payload"`
+}
+
+// encodedEvent is an event serialized once, in every protocol version and format, and shared
+// by every client of the room
+type encodedEvent struct {
+	seq      int64
+	audience Audience
+	frames   [latestProtocol][]byte
+	// packed is the version 2 envelope in MessagePack
+	packed []byte
+}
+
+// frame returns the event in the given protocol version and format. MessagePack is only
+// offered with version 2.
+func (e encodedEvent) frame(version protocolVersion, format wireFormat) []byte {
+	if format == formatMsgPack {
+		return e.packed
+	}
+	return e.frames[version-1]
+}
+
+func encodeEvent(msg Message) (encodedEvent, error) {
+	event := encodedEvent{seq: msg.Seq, audience: msg.Audience}
+
+	legacy, err := json.Marshal(msg)
+	if err != nil {
+		return encodedEvent{}, err
+	}
+	event.frames[protocolV1-1] = legacy
+
+	envelope, err := json.Marshal(newEnvelope(msg))
+	if err != nil {
+		return encodedEvent{}, err
+	}
+	event.frames[protocolV2-1] = envelope
+
+	event.packed, err = packJSON(envelope)
+	if err != nil {
+		return encodedEvent{}, err
+	}
+
+	return event, nil
+}
+
+// packJSON converts a JSON document to MessagePack. Going through JSON keeps the field names,
+// omitted fields and formats (UUIDs, timestamps) identical to the published JSON Schema.
+func packJSON(data []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	return msgpack.Marshal(packable(value))
+}
+
+// packable turns the numbers of a decoded JSON document into integers when they have no
+// fraction, so counts and sequence numbers stay integers in MessagePack
+func packable(value any) any {
+	switch v := value.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]any:
+		for key, item := range v {
+			v[key] = packable(item)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = packable(item)
+		}
+	}
+	return value
+}
+
+// decodeCommand reads a command sent as a JSON text frame or, by MessagePack clients, as a
+// binary frame with the same field names
+func decodeCommand(messageType int, data []byte, cmd *Command) error {
+	if messageType == websocket.BinaryMessage {
+		decoder := msgpack.NewDecoder(bytes.NewReader(data))
+		decoder.SetCustomStructTag("json")
+		return decoder.Decode(cmd)
+	}
+	return json.Unmarshal(data, cmd)
+}
+
+// newEnvelope wraps msg in the version 2 envelope. Events of the room log have a stable ID,
+// derived from their sequence number, so clients can discard duplicates after a replay.
+func newEnvelope(msg Message) Envelope {
+	id := uuid.NewString()
+	if msg.Seq > 0 {
+		id = fmt.Sprintf("%s:%d", msg.RoomID, msg.Seq)
+	}
+
+	timestamp := msg.OccurredAt
+	if timestamp.IsZero() {
+		timestamp = time.Now().UTC()
+	}
+
+	return Envelope{
+		Version:   int(protocolV2),
+		ID:        id,
+		Type:      msg.Kind,
+		RoomID:    msg.RoomID,
+		Timestamp: timestamp,
+		Seq:       msg.Seq,
+		Payload:   msg.Value,
+	}
+}
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHostTokenFromSubprotocols(t *testing.T) {
+	for header, want := range map[string]string{
+		"":                                 "",
+		"ama.v2":                           "",
+		"ama.v2, ama.host-token.abc-123":   "abc-123",
+		"ama.host-token.abc-123,ama.v1":    "abc-123",
+		"ama.v2.msgpack, ama.host-token.":  "",
+		"ama.v2, other.host-token.abc-123": "",
+	} {
+		r := httptest.NewRequest("GET", "/subscribe/room", nil)
+		if header != "" {
+			r.Header.Set("Sec-WebSocket-Protocol", header)
+		}
+		if got := hostTokenFromSubprotocols(r); got != want {
+			t.Errorf("Sec-WebSocket-Protocol %q: token = %q, want %q", header, got, want)
+		}
+	}
+}
+
+func TestHostTokenSubprotocolIsNeverNegotiated(t *testing.T) {
+	for _, subprotocol := range supportedSubprotocols {
+		if strings.HasPrefix(subprotocol, SubprotocolHostTokenPrefix) {
+			t.Errorf("supported subprotocol %q carries the host token prefix", subprotocol)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+const (
+	// Janela em que aumentos de reação da mesma mensagem viram um único evento
+	reactionCoalesceWindow = 250 * time.Millisecond
+	// Travas que ordenam os envios; mensagens diferentes raramente disputam a mesma
+	reactionPublishLocks = 64
+)
+
+// reactionCoalescer merges the message_reaction_increased events of a message raised within
+// a short window into one event carrying the highest count, so a burst of reactions costs the
+// room one frame per window instead of one per reaction.
+//
+// The events of one message are published one at a time, from taking the held event to the
+// end of publish, so a decrease is never sequenced before an increase that was already
+// leaving when it arrived.
+type reactionCoalescer struct {
+	mu      sync.Mutex
+	window  time.Duration
+	pending map[string]Message
+	publish func(Message)
+
+	publishing [reactionPublishLocks]sync.Mutex
+}
+
+func newReactionCoalescer(publish func(Message)) *reactionCoalescer {
+	return &reactionCoalescer{
+		window:  reactionCoalesceWindow,
+		pending: make(map[string]Message),
+		publish: publish,
+	}
+}
+
+// increased holds the event of a new reaction to messageID until the window ends
+func (c *reactionCoalescer) increased(messageID string, msg Message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if held, ok := c.pending[messageID]; ok {
+		// As contagens só crescem entre dois envios; a maior é a mais recente
+		if reactionCount(msg) > reactionCount(held) {
+			c.pending[messageID] = msg
+		}
+		return
+	}
+
+	c.pending[messageID] = msg
+	time.AfterFunc(c.window, func() { c.flush(messageID) })
+}
+
+// flush publishes the held event of messageID right away, if any
+func (c *reactionCoalescer) flush(messageID string) {
+	lock := c.publishLock(messageID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if msg, ok := c.take(messageID); ok {
+		c.publish(msg)
+	}
+}
+
+// decreased publishes the event of a removed reaction, after the held increase of messageID
+// and after any increase still being published, so the last count clients see is this one
+func (c *reactionCoalescer) decreased(messageID string, msg Message) {
+	lock := c.publishLock(messageID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if held, ok := c.take(messageID); ok {
+		c.publish(held)
+	}
+	c.publish(msg)
+}
+
+// take removes and returns the held event of messageID
+func (c *reactionCoalescer) take(messageID string) (Message, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	msg, ok := c.pending[messageID]
+	delete(c.pending, messageID)
+	return msg, ok
+}
+
+func (c *reactionCoalescer) publishLock(messageID string) *sync.Mutex {
+	h := fnv.New32a()
+	h.Write([]byte(messageID))
+	return &c.publishing[h.Sum32()%reactionPublishLocks]
+}
+
+func reactionCount(msg Message) int64 {
+	if value, ok := msg.Value.(MessageMessageReactionIncreased); ok {
+		return value.Count
+	}
+	return 0
+}
+
+// flushAll hands every held event to publish right away, instead of the coalescer's own
+// publish function
+func (c *reactionCoalescer) flushAll(publish func(Message)) {
+	c.mu.Lock()
+	held := c.pending
+	c.pending = make(map[string]Message)
+	c.mu.Unlock()
+
+	for messageID, msg := range held {
+		lock := c.publishLock(messageID)
+		lock.Lock()
+		publish(msg)
+		lock.Unlock()
+	}
+}
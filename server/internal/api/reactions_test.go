@@ -0,0 +1,106 @@
+package api
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func reactionEvent(kind string, count int64) Message {
+	if kind == MessageKindMessageReactionDecreased {
+		return Message{Kind: kind, Value: MessageMessageReactionDecreased{ID: "m1", Count: count}}
+	}
+	return Message{Kind: kind, Value: MessageMessageReactionIncreased{ID: "m1", Count: count}}
+}
+
+func TestReactionCoalescerKeepsHighestCount(t *testing.T) {
+	published := make(chan Message, 4)
+	c := newReactionCoalescer(func(msg Message) { published <- msg })
+	c.window = 10 * time.Millisecond
+
+	c.increased("m1", reactionEvent(MessageKindMessageReactionIncreased, 1))
+	c.increased("m1", reactionEvent(MessageKindMessageReactionIncreased, 3))
+	c.increased("m1", reactionEvent(MessageKindMessageReactionIncreased, 2))
+
+	if got := reactionCount(<-published); got != 3 {
+		t.Fatalf("published count %d, want 3", got)
+	}
+	select {
+	case msg := <-published:
+		t.Fatalf("published a second event %+v", msg)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestReactionDecreaseWaitsForIncreaseBeingPublished(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+	entered := make(chan struct{})
+	release := make(chan struct{})
+
+	c := newReactionCoalescer(func(msg Message) {
+		if msg.Kind == MessageKindMessageReactionIncreased {
+			// O aumento fica preso no meio do envio
+			close(entered)
+			<-release
+		}
+		mu.Lock()
+		order = append(order, msg.Kind)
+		mu.Unlock()
+	})
+
+	c.increased("m1", reactionEvent(MessageKindMessageReactionIncreased, 5))
+
+	// O timer da janela já retirou o aumento e está enviando quando a remoção chega
+	go c.flush("m1")
+	<-entered
+
+	done := make(chan struct{})
+	go func() {
+		c.decreased("m1", reactionEvent(MessageKindMessageReactionDecreased, 4))
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("decrease published while the increase was still being published")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+	<-done
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{MessageKindMessageReactionIncreased, MessageKindMessageReactionDecreased}
+	if len(order) != 2 || order[0] != want[0] || order[1] != want[1] {
+		t.Fatalf("published %v, want %v", order, want)
+	}
+}
+
+func TestReactionDecreasePublishesHeldIncreaseFirst(t *testing.T) {
+	var order []int64
+	c := newReactionCoalescer(func(msg Message) {
+		if value, ok := msg.Value.(MessageMessageReactionDecreased); ok {
+			order = append(order, -value.Count)
+			return
+		}
+		order = append(order, reactionCount(msg))
+	})
+	c.window = time.Hour
+
+	c.increased("m1", reactionEvent(MessageKindMessageReactionIncreased, 5))
+	c.decreased("m1", reactionEvent(MessageKindMessageReactionDecreased, 4))
+
+	if len(order) != 2 || order[0] != 5 || order[1] != -4 {
+		t.Fatalf("published %v, want the increase to 5, then the decrease to 4", order)
+	}
+
+	// O timer da janela não encontra mais nada
+	c.flush("m1")
+	if len(order) != 2 {
+		t.Fatalf("flush published again: %v", order)
+	}
+}
@@ -10,16 +10,20 @@ import (
 	"github.com/JeanGrijp/ask-me-anything/internal/logger"
 	"github.com/JeanGrijp/ask-me-anything/internal/middleware"
 	"github.com/JeanGrijp/ask-me-anything/internal/store/pgstore"
+	"github.com/JeanGrijp/ask-me-anything/internal/webhooks"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 func (h apiHandler) handleCreateRoom(w http.ResponseWriter, r *http.Request) {
 	logger.Default.Info(r.Context(), "creating new room")
 
 	type _body struct {
-		Theme string `json:"theme"`
+		Theme      string `json:"theme"`
+		TemplateID string `json:"template_id"`
+		Visibility string `json:"visibility"`
 	}
 	var body _body
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
@@ -28,13 +32,40 @@ func (h apiHandler) handleCreateRoom(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	logger.Default.Debug(r.Context(), "creating room with theme", "theme", body.Theme)
+	visibility := RoomVisibilityPublic
+	if body.Visibility != "" {
+		if !isRoomVisibility(body.Visibility) {
+			http.Error(w, "visibility must be public or unlisted", http.StatusBadRequest)
+			return
+		}
+		visibility = body.Visibility
+	}
+
+	theme := body.Theme
+	var questions []string
+
+	// Sala criada a partir de um template salvo pela sessão atual
+	if body.TemplateID != "" {
+		template, ok := h.readRoomTemplate(w, r, body.TemplateID)
+		if !ok {
+			return
+		}
+
+		if theme == "" {
+			theme = template.Theme
+		}
+		questions = template.Questions
+
+		logger.Default.Debug(r.Context(), "creating room from template", "template_id", body.TemplateID, "question_count", len(questions))
+	}
+
+	logger.Default.Debug(r.Context(), "creating room with theme", "theme", theme)
 
 	// Adicionar timeout para operação de banco de dados
-	dbCtx, cancel := WithDatabaseTimeout(r.Context())
+	dbCtx, cancel := h.withDatabaseTimeout(r.Context())
 	defer cancel()
 
-	roomID, err := h.q.InsertRoom(dbCtx, body.Theme)
+	room, err := h.insertRoom(dbCtx, theme, visibility, questions)
 	if err != nil {
 		logger.Default.Error(r.Context(), "failed to insert room", "error", err)
 
@@ -51,7 +82,38 @@ func (h apiHandler) handleCreateRoom(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	logger.Default.Info(r.Context(), "room created successfully", "room_id", roomID.String())
+	logger.Default.Info(r.Context(), "room created successfully", "room_id", room.ID.String(), "visibility", visibility)
+
+	h.sendRoomCreated(w, r, room)
+}
+
+// insertRoom cria a sala e, se houver, suas perguntas iniciais numa única transação
+func (h apiHandler) insertRoom(ctx context.Context, theme, visibility string, questions []string) (pgstore.Room, error) {
+	var roomID uuid.UUID
+	err := h.withTx(ctx, func(q *pgstore.Queries) error {
+		var err error
+		roomID, err = q.InsertRoom(ctx, pgstore.InsertRoomParams{Theme: theme, Visibility: visibility})
+		if err != nil {
+			return err
+		}
+
+		if len(questions) == 0 {
+			return nil
+		}
+
+		_, err = q.InsertRoomMessages(ctx, pgstore.InsertRoomMessagesParams{
+			RoomID:   roomID,
+			Messages: questions,
+		})
+		return err
+	})
+	return pgstore.Room{ID: roomID, Theme: theme, Visibility: visibility}, err
+}
+
+// sendRoomCreated registra o criador da sala, cria a sessão de host, responde com o token e
+// anuncia a sala no lobby se ela for pública
+func (h apiHandler) sendRoomCreated(w http.ResponseWriter, r *http.Request, room pgstore.Room) {
+	roomID := room.ID
 
 	// Set the current user as the room creator
 	if err := h.setRoomCreator(r, roomID); err != nil {
@@ -73,6 +135,13 @@ func (h apiHandler) handleCreateRoom(w http.ResponseWriter, r *http.Request) {
 		ID:        roomID.String(),
 		HostToken: hostSession.Token,
 	})
+
+	if room.Visibility == RoomVisibilityPublic {
+		go h.notifyLobby(Message{
+			Kind:  MessageKindRoomCreated,
+			Value: MessageRoomCreated{ID: roomID.String(), Theme: room.Theme},
+		})
+	}
 }
 
 func (h apiHandler) handleGetRooms(w http.ResponseWriter, r *http.Request) {
@@ -94,13 +163,87 @@ func (h apiHandler) handleGetRooms(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h apiHandler) handleGetRoom(w http.ResponseWriter, r *http.Request) {
-	room, rawRoomID, _, ok := h.readRoom(w, r)
+	room, rawRoomID, roomID, ok := h.readRoom(w, r)
 	if !ok {
 		return
 	}
 
 	logger.Default.Debug(r.Context(), "fetching room details", "room_id", rawRoomID)
+
+	// Incluir o destaque atual para que quem entrar depois já o veja
+	spotlight, err := h.getRoomSpotlight(r.Context(), roomID)
+	if err != nil {
+		logger.Default.Error(r.Context(), "failed to get room spotlight", "room_id", rawRoomID, "error", err)
+		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		return
+	}
+
+	type response struct {
+		pgstore.Room
+		Spotlight *SpotlightResponse `json:"spotlight"`
+		Viewers   int                `json:"viewers"`
+	}
+
+	sendJSON(w, response{
+		Room:      room,
+		Spotlight: spotlight,
+		Viewers:   h.presence.viewers(rawRoomID),
+	})
+}
+
+// handleUpdateRoom changes the theme and/or the visibility of a room (host only). The room
+// and the lobby are notified.
+func (h apiHandler) handleUpdateRoom(w http.ResponseWriter, r *http.Request) {
+	_, rawRoomID, roomID, ok := h.readRoom(w, r)
+	if !ok {
+		return
+	}
+
+	type _body struct {
+		Theme      *string `json:"theme"`
+		Visibility *string `json:"visibility"`
+	}
+	var body _body
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		logger.Default.Warn(r.Context(), "invalid JSON in update room request", "room_id", rawRoomID, "error", err)
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+
+	params := pgstore.UpdateRoomParams{ID: roomID}
+	if body.Theme != nil {
+		if *body.Theme == "" {
+			http.Error(w, "theme cannot be empty", http.StatusBadRequest)
+			return
+		}
+		params.Theme = pgtype.Text{String: *body.Theme, Valid: true}
+	}
+	if body.Visibility != nil {
+		if !isRoomVisibility(*body.Visibility) {
+			http.Error(w, "visibility must be public or unlisted", http.StatusBadRequest)
+			return
+		}
+		params.Visibility = pgtype.Text{String: *body.Visibility, Valid: true}
+	}
+
+	updated, err := h.q.UpdateRoom(r.Context(), params)
+	if err != nil {
+		logger.Default.Error(r.Context(), "failed to update room", "room_id", rawRoomID, "error", err)
+		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		return
+	}
+
+	logger.Default.Info(r.Context(), "room updated", "room_id", rawRoomID, "visibility", updated.Visibility, "previous_visibility", updated.PreviousVisibility)
+
+	room := pgstore.Room{ID: updated.ID, Theme: updated.Theme, Visibility: updated.Visibility}
 	sendJSON(w, room)
+
+	go h.notifyClients(Message{
+		Kind:   MessageKindRoomUpdated,
+		RoomID: rawRoomID,
+		Value:  MessageRoomUpdated{ID: rawRoomID, Theme: room.Theme, Visibility: room.Visibility},
+	})
+	go h.announceRoomUpdate(updated.PreviousVisibility, room)
 }
 
 func (h apiHandler) handleGetHostStatus(w http.ResponseWriter, r *http.Request) {
@@ -141,7 +284,7 @@ func (h apiHandler) handleCreateRoomMessage(w http.ResponseWriter, r *http.Reque
 
 	logger.Default.Debug(r.Context(), "creating message", "room_id", rawRoomID, "message_length", len(body.Message))
 
-	messageID, err := h.q.InsertMessage(r.Context(), pgstore.InsertMessageParams{RoomID: roomID, Message: body.Message})
+	messageID, err := h.createRoomMessage(r.Context(), rawRoomID, roomID, body.Message)
 	if err != nil {
 		logger.Default.Error(r.Context(), "failed to insert message", "error", err)
 		http.Error(w, "something went wrong", http.StatusInternalServerError)
@@ -155,15 +298,36 @@ func (h apiHandler) handleCreateRoomMessage(w http.ResponseWriter, r *http.Reque
 	}
 
 	sendJSON(w, response{ID: messageID.String()})
+}
+
+// createRoomMessage stores a question asked in the room and notifies the room.
+// It is shared by the REST handler and the WebSocket post_question command.
+func (h apiHandler) createRoomMessage(ctx context.Context, rawRoomID string, roomID uuid.UUID, message string) (uuid.UUID, error) {
+	// Vincular a pergunta à sessão de quem perguntou, quando houver
+	var authorSessionID pgtype.UUID
+	if session, hasSession := middleware.GetUserSessionFromContext(ctx); hasSession {
+		authorSessionID = pgtype.UUID{Bytes: session.ID, Valid: true}
+	}
+
+	messageID, err := h.q.InsertMessage(ctx, pgstore.InsertMessageParams{
+		RoomID:          roomID,
+		Message:         message,
+		AuthorSessionID: authorSessionID,
+	})
+	if err != nil {
+		return uuid.UUID{}, err
+	}
 
 	go h.notifyClients(Message{
 		Kind:   MessageKindMessageCreated,
 		RoomID: rawRoomID,
 		Value: MessageMessageCreated{
 			ID:      messageID.String(),
-			Message: body.Message,
+			Message: message,
 		},
 	})
+
+	return messageID, nil
 }
 
 func (h apiHandler) handleGetRoomMessages(w http.ResponseWriter, r *http.Request) {
@@ -205,7 +369,7 @@ func (h apiHandler) handleGetRoomMessages(w http.ResponseWriter, r *http.Request
 		}
 
 		if messages == nil {
-			messages = []pgstore.Message{}
+			messages = []pgstore.GetRoomMessagesRow{}
 		}
 
 		logger.Default.Debug(r.Context(), "basic messages fetched successfully", "room_id", rawRoomID, "count", len(messages))
@@ -246,6 +410,8 @@ func (h apiHandler) handleGetRoomMessage(w http.ResponseWriter, r *http.Request)
 	sendJSON(w, messages)
 }
 
+var errNotRoomCreator = errors.New("session is not the room creator")
+
 func (h apiHandler) handleDeleteRoom(w http.ResponseWriter, r *http.Request) {
 	rawRoomID := chi.URLParam(r, "room_id")
 	roomID, err := uuid.Parse(rawRoomID)
@@ -265,24 +431,51 @@ func (h apiHandler) handleDeleteRoom(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	deleted := MessageRoomDeleted{
+		ID:     rawRoomID,
+		Reason: "deleted_by_creator",
+	}
+
+	// O lobby só fica sabendo da remoção de salas públicas
+	room, err := h.q.GetRoom(r.Context(), roomID)
+	wasPublic := err == nil && room.Visibility == RoomVisibilityPublic
+
 	// Delete room and all associated data (query includes ownership verification)
-	rowsAffected, err := h.q.DeleteRoomAndMessages(r.Context(), pgstore.DeleteRoomAndMessagesParams{
-		ID:           roomID,
-		SessionToken: session.SessionToken,
+	var rowsAffected int64
+	err = h.withTx(r.Context(), func(q *pgstore.Queries) error {
+		// Os webhooks são enfileirados antes da remoção, enquanto as assinaturas da sala ainda existem
+		if _, err := webhooks.Enqueue(r.Context(), q, roomID, MessageKindRoomDeleted, deleted); err != nil {
+			return err
+		}
+
+		rowsAffected, err = q.DeleteRoomAndMessages(r.Context(), pgstore.DeleteRoomAndMessagesParams{
+			ID:           roomID,
+			SessionToken: session.SessionToken,
+		})
+		if err != nil {
+			return err
+		}
+
+		// Desfaz o enfileiramento se a sala não foi removida
+		if rowsAffected == 0 {
+			return errNotRoomCreator
+		}
+		return nil
 	})
-	if err != nil {
-		logger.Default.Error(r.Context(), "failed to delete room", "room_id", rawRoomID, "error", err)
-		http.Error(w, "failed to delete room", http.StatusInternalServerError)
-		return
-	}
 
 	// Check if room was actually deleted (user was the creator)
-	if rowsAffected == 0 {
+	if errors.Is(err, errNotRoomCreator) {
 		logger.Default.Warn(r.Context(), "room deletion denied - not creator", "room_id", rawRoomID)
 		http.Error(w, "only room creator can delete this room", http.StatusForbidden)
 		return
 	}
 
+	if err != nil {
+		logger.Default.Error(r.Context(), "failed to delete room", "room_id", rawRoomID, "error", err)
+		http.Error(w, "failed to delete room", http.StatusInternalServerError)
+		return
+	}
+
 	logger.Default.Info(r.Context(), "room deleted successfully", "room_id", rawRoomID, "rows_affected", rowsAffected)
 
 	// Send success response
@@ -292,9 +485,13 @@ func (h apiHandler) handleDeleteRoom(w http.ResponseWriter, r *http.Request) {
 	go h.notifyClients(Message{
 		Kind:   MessageKindRoomDeleted,
 		RoomID: rawRoomID,
-		Value: MessageRoomDeleted{
-			ID:     rawRoomID,
-			Reason: "deleted_by_creator",
-		},
+		Value:  deleted,
 	})
+
+	if wasPublic {
+		go h.notifyLobby(Message{
+			Kind:  MessageKindRoomDeleted,
+			Value: deleted,
+		})
+	}
 }
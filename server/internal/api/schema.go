@@ -0,0 +1,139 @@
+package api
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// eventType describes the payload of one event kind
+type eventType struct {
+	kind        string
+	description string
+	payload     any
+}
+
+// eventTypes lists every event a realtime client can receive. New kinds must be added here
+// so their schema is published.
+var eventTypes = []eventType{
+	{MessageKindMessageCreated, "A question was asked in the room.", MessageMessageCreated{}},
+	{MessageKindMessageReactionIncreased, "A question received a reaction.", MessageMessageReactionIncreased{}},
+	{MessageKindMessageReactionDecreased, "A reaction was removed from a question.", MessageMessageReactionDecreased{}},
+	{MessageKindMessageAnswered, "The host marked a question as answered.", MessageMessageAnswered{}},
+	{MessageKindMessagesImported, "The host imported questions in bulk.", MessageMessagesImported{}},
+	{MessageKindMessageSpotlighted, "The host changed the spotlighted question; a null spotlight clears it.", MessageMessageSpotlighted{}},
+	{MessageKindPollOpened, "The host opened a poll.", MessagePollOpened{}},
+	{MessageKindPollResultsUpdated, "The results of an open poll changed.", MessagePollResultsUpdated{}},
+	{MessageKindPollClosed, "The host closed a poll.", MessagePollClosed{}},
+	{MessageKindRoomUpdated, "The host changed the theme or the visibility of the room. Also sent to the lobby for public rooms.", MessageRoomUpdated{}},
+	{MessageKindRoomDeleted, "The room was deleted; no more events follow. The lobby also receives it when a public room is deleted or unlisted.", MessageRoomDeleted{}},
+	{MessageKindRoomCreated, "A public room was created or made public. Sent to the lobby.", MessageRoomCreated{}},
+	{MessageKindRoomActivity, "The counters of a public room changed. Sent to the lobby at most once per room every few seconds.", MessageRoomActivity{}},
+	{MessageKindResyncRequired, "The missed events are no longer available: reload the room and continue from last_seq.", MessageResyncRequired{}},
+	{MessageKindPresenceUpdated, "The number of viewers of the room changed.", MessagePresenceUpdated{}},
+	{MessageKindPresenceDetail, "Who is connected to the room. Sent to hosts only.", PresenceResponse{}},
+	{MessageKindYourQuestionAnswered, "A question asked by this session was answered. Sent to every connection of the author, in any room.", MessageYourQuestionAnswered{}},
+	{MessageKindFollowedQuestionAnswered, "A question followed by this session was answered. Sent to every connection of the follower, in any room.", MessageFollowedQuestion{}},
+	{MessageKindFollowedQuestionSpotlighted, "A question followed by this session was spotlighted. Sent to every connection of the follower, in any room. Questions cannot be merged or dismissed, so those changes have no event.", MessageFollowedQuestion{}},
+	{MessageKindServerShuttingDown, "The server is restarting and closes the connection next. Reconnect after reconnect_after_ms plus a random delay of up to reconnect_jitter_ms.", MessageServerShuttingDown{}},
+	{MessageKindCommandAck, "A WebSocket command succeeded. Sent to the connection that issued it.", MessageCommandAck{}},
+	{MessageKindCommandError, "A WebSocket command was rejected. Sent to the connection that issued it.", MessageCommandError{}},
+}
+
+// EventSchemaResponse publishes the realtime protocol: the version 2 envelope and the
+// payload of every event kind, as JSON Schema
+type EventSchemaResponse struct {
+	Schema       string                    `json:"$schema"`
+	Versions     []int                     `json:"versions"`
+	Subprotocols map[string]int            `json:"subprotocols"`
+	Envelope     map[string]any            `json:"envelope"`
+	Events       map[string]map[string]any `json:"events"`
+}
+
+var eventSchema = sync.OnceValue(func() EventSchemaResponse {
+	response := EventSchemaResponse{
+		Schema:   jsonSchemaDialect,
+		Versions: []int{int(protocolV1), int(protocolV2)},
+		// Subprotocolo → versão; ama.v2.msgpack leva o mesmo envelope em MessagePack
+		Subprotocols: map[string]int{
+			SubprotocolV1:        int(protocolV1),
+			SubprotocolV2:        int(protocolV2),
+			SubprotocolV2MsgPack: int(protocolV2),
+		},
+		Envelope: jsonSchemaOf(reflect.TypeFor[Envelope]()),
+		Events:   make(map[string]map[string]any, len(eventTypes)),
+	}
+
+	response.Envelope["title"] = "envelope"
+
+	for _, event := range eventTypes {
+		schema := jsonSchemaOf(reflect.TypeOf(event.payload))
+		schema["title"] = event.kind
+		schema["description"] = event.description
+		response.Events[event.kind] = schema
+	}
+
+	return response
+})
+
+// handleGetEventSchema serves the JSON Schema of the realtime events
+func (h apiHandler) handleGetEventSchema(w http.ResponseWriter, r *http.Request) {
+	sendJSON(w, eventSchema())
+}
+
+// jsonSchemaOf describes t as JSON Schema, following the encoding/json rules for struct tags
+func jsonSchemaOf(t reflect.Type) map[string]any {
+	if t == reflect.TypeFor[time.Time]() {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return map[string]any{"anyOf": []any{jsonSchemaOf(t.Elem()), map[string]any{"type": "null"}}}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": jsonSchemaOf(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": jsonSchemaOf(t.Elem())}
+	case reflect.Struct:
+		properties := make(map[string]any)
+		required := []string{}
+
+		for i := range t.NumField() {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+
+			properties[name] = jsonSchemaOf(field.Type)
+			if !strings.Contains(options, "omitempty") {
+				required = append(required, name)
+			}
+		}
+
+		return map[string]any{"type": "object", "properties": properties, "required": required}
+	}
+
+	// any: qualquer valor JSON
+	return map[string]any{}
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/JeanGrijp/ask-me-anything/internal/logger"
+)
+
+const MessageKindServerShuttingDown = "server_shutting_down"
+
+const (
+	// Espera sugerida aos clientes antes de reconectar, para dar tempo de outra instância assumir
+	shutdownReconnectAfter = 1 * time.Second
+	// Atraso aleatório extra sugerido, para que os clientes não reconectem todos juntos
+	shutdownReconnectJitter = 5 * time.Second
+)
+
+type MessageServerShuttingDown struct {
+	// ReconnectAfterMS is how long the client should wait before reconnecting
+	ReconnectAfterMS int64 `json:"reconnect_after_ms"`
+	// ReconnectJitterMS is the upper bound of a random delay added to ReconnectAfterMS
+	ReconnectJitterMS int64 `json:"reconnect_jitter_ms"`
+}
+
+// Handler is the HTTP handler of the API. Its realtime connections are hijacked or
+// long-lived, so http.Server.Shutdown does not end them: call Shutdown as well.
+type Handler struct {
+	http.Handler
+
+	api apiHandler
+	// stop cancels the background goroutines: webhook dispatcher, broadcaster, lobby and sampler
+	stop context.CancelFunc
+}
+
+// Shutdown tells every realtime client the server is going away, closes their connections
+// with a close frame once their queued events are written and stops the background work.
+// It returns ctx.Err() if the clients were not drained before ctx is done.
+func (h *Handler) Shutdown(ctx context.Context) error {
+	// Reações retidas saem antes do aviso, com as contagens finais. Pelo broadcaster chegariam
+	// depois do fechamento, então são entregues aqui mesmo aos clientes desta instância
+	h.api.reactions.flushAll(h.api.notifyClientsNow)
+
+	event, err := encodeEvent(Message{
+		Kind: MessageKindServerShuttingDown,
+		Value: MessageServerShuttingDown{
+			ReconnectAfterMS:  shutdownReconnectAfter.Milliseconds(),
+			ReconnectJitterMS: shutdownReconnectJitter.Milliseconds(),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	clients := h.api.hub.shutdown(event)
+	logger.Default.Info(ctx, "disconnecting realtime clients", "clients", len(clients))
+
+	err = waitClients(ctx, clients)
+
+	h.stop()
+	h.api.sessionMgr.Stop()
+
+	return err
+}
+
+// waitClients waits until the writer goroutine of every client has stopped
+func waitClients(ctx context.Context, clients []*client) error {
+	for _, c := range clients {
+		select {
+		case <-c.stopped:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/JeanGrijp/ask-me-anything/internal/logger"
+	"github.com/JeanGrijp/ask-me-anything/internal/store/pgstore"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// SpotlightResponse represents the message currently being answered in a room
+type SpotlightResponse struct {
+	MessageID     string `json:"message_id"`
+	Message       string `json:"message"`
+	ReactionCount int64  `json:"reaction_count"`
+	Answered      bool   `json:"answered"`
+	SpotlightedAt string `json:"spotlighted_at"`
+}
+
+// handleSpotlightMessage highlights a message for every viewer of the room (host only)
+func (h apiHandler) handleSpotlightMessage(w http.ResponseWriter, r *http.Request) {
+	_, rawRoomID, roomID, ok := h.readRoom(w, r)
+	if !ok {
+		return
+	}
+
+	rawID := chi.URLParam(r, "message_id")
+	id, err := uuid.Parse(rawID)
+	if err != nil {
+		logger.Default.Warn(r.Context(), "invalid message ID in spotlight request", "message_id", rawID, "error", err)
+		http.Error(w, "invalid message id", http.StatusBadRequest)
+		return
+	}
+
+	message, err := h.q.GetMessage(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			logger.Default.Warn(r.Context(), "message not found for spotlight", "room_id", rawRoomID, "message_id", rawID)
+			http.Error(w, "message not found", http.StatusNotFound)
+			return
+		}
+
+		logger.Default.Error(r.Context(), "failed to get message for spotlight", "room_id", rawRoomID, "message_id", rawID, "error", err)
+		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		return
+	}
+
+	// A mensagem precisa pertencer à sala
+	if message.RoomID != roomID {
+		logger.Default.Warn(r.Context(), "message does not belong to room", "room_id", rawRoomID, "message_id", rawID)
+		http.Error(w, "message not found", http.StatusNotFound)
+		return
+	}
+
+	if err := h.q.SetRoomSpotlight(r.Context(), pgstore.SetRoomSpotlightParams{RoomID: roomID, MessageID: id}); err != nil {
+		logger.Default.Error(r.Context(), "failed to set room spotlight", "room_id", rawRoomID, "message_id", rawID, "error", err)
+		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		return
+	}
+
+	spotlight, err := h.getRoomSpotlight(r.Context(), roomID)
+	if err != nil || spotlight == nil {
+		logger.Default.Error(r.Context(), "failed to read room spotlight", "room_id", rawRoomID, "error", err)
+		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		return
+	}
+
+	logger.Default.Info(r.Context(), "message spotlighted", "room_id", rawRoomID, "message_id", rawID)
+
+	sendJSON(w, spotlight)
+
+	go h.notifyClients(Message{
+		Kind:   MessageKindMessageSpotlighted,
+		RoomID: rawRoomID,
+		Value:  MessageMessageSpotlighted{Spotlight: spotlight},
+	})
+	go h.notifyFollowers(id, MessageKindFollowedQuestionSpotlighted)
+}
+
+// handleClearSpotlight removes the current spotlight of the room (host only)
+func (h apiHandler) handleClearSpotlight(w http.ResponseWriter, r *http.Request) {
+	_, rawRoomID, roomID, ok := h.readRoom(w, r)
+	if !ok {
+		return
+	}
+
+	rowsAffected, err := h.q.ClearRoomSpotlight(r.Context(), roomID)
+	if err != nil {
+		logger.Default.Error(r.Context(), "failed to clear room spotlight", "room_id", rawRoomID, "error", err)
+		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+
+	// Nada a notificar se não havia destaque
+	if rowsAffected == 0 {
+		return
+	}
+
+	logger.Default.Info(r.Context(), "room spotlight cleared", "room_id", rawRoomID)
+
+	go h.notifyClients(Message{
+		Kind:   MessageKindMessageSpotlighted,
+		RoomID: rawRoomID,
+		Value:  MessageMessageSpotlighted{Spotlight: nil},
+	})
+}
+
+// getRoomSpotlight returns the current spotlight of a room, or nil if there is none
+func (h apiHandler) getRoomSpotlight(ctx context.Context, roomID uuid.UUID) (*SpotlightResponse, error) {
+	row, err := h.q.GetRoomSpotlight(ctx, roomID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &SpotlightResponse{
+		MessageID:     row.ID.String(),
+		Message:       row.Message,
+		ReactionCount: row.ReactionCount,
+		Answered:      row.Answered,
+		SpotlightedAt: formatTimestamp(row.SpotlightedAt.Time),
+	}, nil
+}
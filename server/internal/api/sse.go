@@ -0,0 +1,140 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/JeanGrijp/ask-me-anything/internal/auth"
+	"github.com/JeanGrijp/ask-me-anything/internal/logger"
+)
+
+const (
+	// Intervalo dos comentários enviados para manter a conexão SSE aberta em proxies
+	sseHeartbeatInterval = 15 * time.Second
+	// Tempo sugerido ao EventSource antes de reconectar
+	sseRetryInterval = 3 * time.Second
+)
+
+// sseSubscriber sends events over a text/event-stream response
+type sseSubscriber struct {
+	w       io.Writer
+	rc      *http.ResponseController
+	version protocolVersion
+	// writeTimeout bounds each frame written to the stream
+	writeTimeout time.Duration
+}
+
+func newSSESubscriber(w http.ResponseWriter, version protocolVersion, writeTimeout time.Duration) *sseSubscriber {
+	return &sseSubscriber{w: w, rc: http.NewResponseController(w), version: version, writeTimeout: writeTimeout}
+}
+
+// write sends the event with the same payload sent to WebSocket clients; its sequence
+// number becomes the SSE id, which the browser sends back as Last-Event-ID
+func (s *sseSubscriber) write(event encodedEvent) error {
+	if event.seq == 0 {
+		return s.writeFrame(fmt.Sprintf("data: %s\n\n", event.frame(s.version, formatJSON)))
+	}
+	return s.writeFrame(fmt.Sprintf("id: %d\ndata: %s\n\n", event.seq, event.frame(s.version, formatJSON)))
+}
+
+// ping writes a comment line, ignored by EventSource, so idle connections are not closed
+func (s *sseSubscriber) ping() error {
+	return s.writeFrame(": heartbeat\n\n")
+}
+
+// close does nothing: SSE has no close frame, the response simply ends
+func (s *sseSubscriber) close(closeReason) {}
+
+func (s *sseSubscriber) writeFrame(frame string) error {
+	_ = s.rc.SetWriteDeadline(time.Now().Add(s.writeTimeout))
+	if _, err := io.WriteString(s.w, frame); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+// handleRoomEvents streams the realtime events of a room as Server-Sent Events, for clients
+// that cannot open a WebSocket. Reconnecting clients send Last-Event-ID (or ?last_event_id=)
+// to receive the events they missed, like ?since= on /subscribe/{room_id}.
+func (h apiHandler) handleRoomEvents(w http.ResponseWriter, r *http.Request) {
+	_, rawRoomID, roomID, ok := h.readRoom(w, r)
+	if !ok {
+		return
+	}
+
+	rawLastEventID := r.Header.Get("Last-Event-ID")
+	if rawLastEventID == "" {
+		rawLastEventID = r.URL.Query().Get("last_event_id")
+	}
+	lastEventID, err := parseEventSeq(rawLastEventID)
+	if err != nil {
+		http.Error(w, "invalid last event id", http.StatusBadRequest)
+		return
+	}
+
+	// ?version=2 escolhe o envelope da versão 2, como o subprotocolo no WebSocket
+	version, err := parseProtocolVersion(r.URL.Query().Get("version"))
+	if err != nil {
+		http.Error(w, "unsupported protocol version", http.StatusBadRequest)
+		return
+	}
+
+	isHost, err := h.isRoomHost(r.Context(), roomID, auth.GetHostToken(r.Context()))
+	if err != nil {
+		logger.Default.Error(r.Context(), "failed to resolve host role", "room_id", rawRoomID, "error", err)
+		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	ctx = WithClientIP(WithRoomID(ctx, rawRoomID), clientIP(r))
+
+	// Sem quadro de fechamento no SSE: acima dos limites a requisição é recusada antes do stream
+	if err := h.hub.admit(identityFromContext(ctx), rawRoomID); err != nil {
+		logger.Default.Warn(ctx, "event stream rejected", "room_id", rawRoomID, "reason", err.Error())
+		rejectStream(w, err)
+		return
+	}
+
+	sub, err := h.openEventStream(w, version)
+	if err != nil {
+		logger.Default.Debug(r.Context(), "failed to start event stream", "room_id", rawRoomID, "error", err)
+		return
+	}
+
+	c, subscriberCount, err := h.subscribe(ctx, rawRoomID, sub, isHost, lastEventID, sseHeartbeatInterval)
+	if err != nil {
+		if _, limited := limitCloseReason(err); !limited {
+			logger.Default.Warn(ctx, "failed to replay events", "room_id", rawRoomID, "error", err)
+		}
+		c.close(closeReason{})
+	}
+
+	logger.Default.Info(ctx, "SSE client connected", "room_id", rawRoomID, "client_ip", r.RemoteAddr, "total_subscribers", subscriberCount)
+
+	h.serveClient(ctx, c)
+
+	logger.Default.Info(context.Background(), "SSE client disconnected", "room_id", rawRoomID, "client_ip", r.RemoteAddr, "remaining_subscribers", h.hub.count(rawRoomID))
+}
+
+// openEventStream starts a Server-Sent Events response and tells the browser how long to
+// wait before reconnecting
+func (h apiHandler) openEventStream(w http.ResponseWriter, version protocolVersion) (*sseSubscriber, error) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// Desativa o buffer de proxies como o nginx
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	sub := newSSESubscriber(w, version, h.timeouts.ClientNotification)
+	if err := sub.writeFrame(fmt.Sprintf("retry: %d\n\n", sseRetryInterval.Milliseconds())); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
@@ -0,0 +1,284 @@
+package api
+
+import (
+	"compress/flate"
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/JeanGrijp/ask-me-anything/internal/logger"
+	"github.com/JeanGrijp/ask-me-anything/internal/middleware"
+	"github.com/gorilla/websocket"
+)
+
+// Tamanho mínimo de um quadro WebSocket para comprimi-lo com permessage-deflate
+const minCompressedFrameSize = 256
+
+// subscriber is the transport of a client connected to the realtime events of a room.
+// WebSocket and Server-Sent Events clients share the same hub; its methods are only
+// called from the client's writer goroutine.
+type subscriber interface {
+	// write sends one encoded event
+	write(event encodedEvent) error
+	// ping keeps an idle connection alive
+	ping() error
+	// close tells the client why the server is disconnecting it
+	close(reason closeReason)
+}
+
+// wsSubscriber sends events over a WebSocket connection in the protocol version and format
+// negotiated through the subprotocol: JSON text frames or MessagePack binary frames
+type wsSubscriber struct {
+	conn    *websocket.Conn
+	version protocolVersion
+	format  wireFormat
+	// writeTimeout bounds each frame written to conn
+	writeTimeout time.Duration
+}
+
+func newWSSubscriber(conn *websocket.Conn, writeTimeout time.Duration) *wsSubscriber {
+	version, format := protocolFromSubprotocol(conn.Subprotocol())
+
+	// Só tem efeito quando o cliente negociou permessage-deflate
+	_ = conn.SetCompressionLevel(flate.BestSpeed)
+
+	return &wsSubscriber{conn: conn, version: version, format: format, writeTimeout: writeTimeout}
+}
+
+func (s *wsSubscriber) write(event encodedEvent) error {
+	messageType := websocket.TextMessage
+	if s.format == formatMsgPack {
+		messageType = websocket.BinaryMessage
+	}
+	frame := event.frame(s.version, s.format)
+
+	// Quadros pequenos crescem com o deflate: só comprime a partir de minCompressedFrameSize
+	s.conn.EnableWriteCompression(len(frame) >= minCompressedFrameSize)
+
+	s.conn.SetWriteDeadline(time.Now().Add(s.writeTimeout))
+	return s.conn.WriteMessage(messageType, frame)
+}
+
+func (s *wsSubscriber) ping() error {
+	s.conn.SetWriteDeadline(time.Now().Add(s.writeTimeout))
+	return s.conn.WriteMessage(websocket.PingMessage, nil)
+}
+
+func (s *wsSubscriber) close(reason closeReason) {
+	message := websocket.FormatCloseMessage(reason.code, reason.text)
+	_ = s.conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(s.writeTimeout))
+}
+
+// connIdentity is the user session and the address of a connection
+type connIdentity struct {
+	// sessionID is empty for anonymous connections
+	sessionID   string
+	displayName string
+	ip          string
+}
+
+// identityFromContext reads the user session resolved for the request of a connection
+// and the address set with WithClientIP
+func identityFromContext(ctx context.Context) connIdentity {
+	identity := connIdentity{ip: GetClientIP(ctx)}
+	if session, ok := middleware.GetUserSessionFromContext(ctx); ok {
+		identity.sessionID = session.ID.String()
+		identity.displayName = session.Username.String
+	}
+	return identity
+}
+
+// connect creates the client of a connection and starts its writer goroutine. The client
+// receives the personal events of its session right away, and room events once it joins a room.
+// Over the connection limits the client is returned already closed, with the reason, and the
+// *connLimitError is returned too.
+func (h apiHandler) connect(ctx context.Context, sub subscriber, pingInterval time.Duration) (*client, error) {
+	c := newClient(sub, identityFromContext(ctx))
+	err := h.hub.register(c)
+	if err != nil {
+		logger.Default.Warn(ctx, "realtime connection rejected", "reason", err.Error(), "client_ip", c.identity.ip)
+	}
+
+	go c.run(pingInterval)
+
+	return c, err
+}
+
+// subscribe connects a client already registered in one room, as /subscribe/{room_id} and
+// the SSE stream do
+func (h apiHandler) subscribe(ctx context.Context, roomID string, sub subscriber, isHost bool, since *int64, pingInterval time.Duration) (*client, int, error) {
+	c, err := h.connect(ctx, sub, pingInterval)
+	if err != nil {
+		return c, h.hub.count(roomID), err
+	}
+
+	count, err := h.joinRoom(ctx, c, roomID, since, isHost)
+
+	return c, count, err
+}
+
+// joinRoom registers c in a room and returns how many clients the room has. When since is
+// not nil, the events after that sequence number are queued first. The stored history is read
+// before the room's lock is taken, so a slow query does not hold back the delivery to the room;
+// the events delivered meanwhile are added under the lock, so the client switches to live events
+// without losing or reordering any of them. On a replay error, or when the room is full
+// (*connLimitError), the client is not registered.
+func (h apiHandler) joinRoom(ctx context.Context, c *client, roomID string, since *int64, isHost bool) (int, error) {
+	if err := h.hub.checkRoom(roomID); err != nil {
+		return h.hub.count(roomID), err
+	}
+
+	m := &membership{isHost: isHost}
+
+	var missed []encodedEvent
+	if since != nil {
+		var err error
+		missed, m.lastSeq, err = h.replay(ctx, roomID, *since, c.identity.sessionID, isHost)
+		if err != nil {
+			return h.hub.count(roomID), err
+		}
+	}
+
+	room := h.events.room(roomID)
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	// A sala pode ter enchido enquanto o histórico era lido
+	if err := h.hub.checkRoom(roomID); err != nil {
+		return h.hub.count(roomID), err
+	}
+
+	if since != nil {
+		tail, lastSeq, err := h.replayTail(ctx, room, roomID, *since, m.lastSeq, c.identity.sessionID, isHost)
+		if err != nil {
+			return h.hub.count(roomID), err
+		}
+		missed, m.lastSeq = append(missed, tail...), lastSeq
+
+		if len(missed) > 0 && !c.send(missed...) {
+			return h.hub.count(roomID), nil
+		}
+	}
+
+	count, ok := h.hub.join(roomID, c, m)
+	if ok {
+		h.presence.join(roomID, c)
+	}
+
+	return count, nil
+}
+
+// leaveRoom removes c from a room and returns how many clients remain in it
+func (h apiHandler) leaveRoom(roomID string, c *client) int {
+	// O lock da sala ordena a saída com uma entrada em andamento, mantendo a presença consistente
+	room := h.events.room(roomID)
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	count, ok := h.hub.leave(roomID, c)
+	if ok {
+		h.presence.leave(roomID, c)
+	}
+
+	return count
+}
+
+// serveClient blocks until the client or its connection goes away, then removes it from
+// the hub
+func (h apiHandler) serveClient(ctx context.Context, c *client) {
+	select {
+	case <-ctx.Done():
+		c.close(closeReason{})
+	case <-c.done:
+	}
+
+	<-c.stopped
+
+	h.hub.unregister(c)
+	h.hub.leaveLobby(c)
+	for _, roomID := range c.roomIDs() {
+		h.leaveRoom(roomID, c)
+	}
+}
+
+// replay returns the events of the room after since that the client may see, or a
+// resync_required event when they are no longer available, together with the last sequence
+// number the client will have seen. It reads the database and must be called without the
+// room lock.
+func (h apiHandler) replay(ctx context.Context, roomID string, since int64, sessionID string, isHost bool) ([]encodedEvent, int64, error) {
+	dbCtx, cancel := h.withDatabaseTimeout(ctx)
+	defer cancel()
+
+	missed, lastSeq, err := h.events.since(dbCtx, roomID, since)
+	if errors.Is(err, errEventGapTooOld) {
+		logger.Default.Info(ctx, "event gap too old, requesting resync", "room_id", roomID, "since", since, "last_seq", lastSeq)
+		return resyncEvents(roomID, since, lastSeq)
+	}
+	if err != nil {
+		return nil, since, err
+	}
+
+	logger.Default.Debug(ctx, "replaying missed events", "room_id", roomID, "since", since, "count", len(missed))
+
+	return encodeReplay(missed, since, sessionID, isHost)
+}
+
+// replayTail returns the events delivered to the room after seen, the last sequence number
+// returned by replay, in the same way as replay. The caller must hold the room lock.
+func (h apiHandler) replayTail(ctx context.Context, room *roomEvents, roomID string, since, seen int64, sessionID string, isHost bool) ([]encodedEvent, int64, error) {
+	missed, lastSeq, ok := h.events.catchUp(room, seen)
+	if !ok {
+		logger.Default.Info(ctx, "events delivered during replay no longer buffered, requesting resync", "room_id", roomID, "since", since, "last_seq", lastSeq)
+		return resyncEvents(roomID, since, lastSeq)
+	}
+
+	return encodeReplay(missed, seen, sessionID, isHost)
+}
+
+// encodeReplay encodes the events of missed that the client may see and returns them with the
+// last sequence number among missed, or since when it is empty
+func encodeReplay(missed []Message, since int64, sessionID string, isHost bool) ([]encodedEvent, int64, error) {
+	events := make([]encodedEvent, 0, len(missed))
+	for _, msg := range missed {
+		since = msg.Seq
+		if !msg.Audience.includes(sessionID, isHost) {
+			continue
+		}
+
+		event, err := encodeEvent(msg)
+		if err != nil {
+			return nil, since, err
+		}
+		events = append(events, event)
+	}
+	return events, since, nil
+}
+
+// resyncEvents tells a client that the events after since can no longer be replayed and that
+// it should reload the state of the room, which is at lastSeq
+func resyncEvents(roomID string, since, lastSeq int64) ([]encodedEvent, int64, error) {
+	event, err := encodeEvent(Message{
+		Kind:   MessageKindResyncRequired,
+		RoomID: roomID,
+		Seq:    lastSeq,
+		Value:  MessageResyncRequired{Since: since, LastSeq: lastSeq},
+	})
+	if err != nil {
+		return nil, since, err
+	}
+	return []encodedEvent{event}, lastSeq, nil
+}
+
+// parseEventSeq parses the sequence number a client has already seen; an empty value means no replay
+func parseEventSeq(raw string) (*int64, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	seq, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || seq < 0 {
+		return nil, errors.New("invalid event sequence")
+	}
+	return &seq, nil
+}
@@ -0,0 +1,368 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/JeanGrijp/ask-me-anything/internal/logger"
+	"github.com/JeanGrijp/ask-me-anything/internal/middleware"
+	"github.com/JeanGrijp/ask-me-anything/internal/responses"
+	"github.com/JeanGrijp/ask-me-anything/internal/store/pgstore"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	maxTemplateNameLength = 100
+	maxTemplateQuestions  = 200
+	maxMessageLength      = 255
+)
+
+var (
+	errTooManyQuestions = fmt.Errorf("rooms can have at most %d questions", maxTemplateQuestions)
+	errInvalidQuestion  = fmt.Errorf("questions must not be empty and must have at most %d characters", maxMessageLength)
+)
+
+// RoomTemplateResponse represents a room configuration saved by the user
+type RoomTemplateResponse struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Theme     string   `json:"theme"`
+	Questions []string `json:"questions"`
+	CreatedAt string   `json:"created_at"`
+}
+
+func newRoomTemplateResponse(template pgstore.RoomTemplate) RoomTemplateResponse {
+	questions := template.Questions
+	if questions == nil {
+		questions = []string{}
+	}
+
+	return RoomTemplateResponse{
+		ID:        template.ID.String(),
+		Name:      template.Name,
+		Theme:     template.Theme,
+		Questions: questions,
+		CreatedAt: formatTimestamp(template.CreatedAt.Time),
+	}
+}
+
+// handleCloneRoom creates a new room with the settings (and optionally the questions) of an
+// existing one (host or creator only)
+func (h apiHandler) handleCloneRoom(w http.ResponseWriter, r *http.Request) {
+	room, rawRoomID, roomID, ok := h.readRoom(w, r)
+	if !ok {
+		return
+	}
+
+	// Como na exportação, só o host ou o criador copia uma sala, que pode não estar listada
+	isHost, err := h.isRoomHost(r.Context(), roomID, r.Header.Get("X-Host-Token"))
+	if err != nil {
+		logger.Default.Error(r.Context(), "failed to check room host", "room_id", rawRoomID, "error", err)
+		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		return
+	}
+	if !isHost {
+		logger.Default.Warn(r.Context(), "room clone denied", "room_id", rawRoomID)
+		http.Error(w, "only room host or creator can clone this room", http.StatusForbidden)
+		return
+	}
+
+	type _body struct {
+		Theme            string `json:"theme"`
+		Visibility       string `json:"visibility"`
+		IncludeQuestions bool   `json:"include_questions"`
+	}
+	var body _body
+	// O corpo é opcional: sem ele a sala é clonada apenas com o tema original
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+		logger.Default.Warn(r.Context(), "invalid JSON in clone room request", "room_id", rawRoomID, "error", err)
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+
+	theme := room.Theme
+	if body.Theme != "" {
+		theme = body.Theme
+	}
+
+	// Sem visibilidade no corpo, o clone mantém a da sala original
+	visibility := room.Visibility
+	if body.Visibility != "" {
+		if !isRoomVisibility(body.Visibility) {
+			http.Error(w, "visibility must be public or unlisted", http.StatusBadRequest)
+			return
+		}
+		visibility = body.Visibility
+	}
+
+	var questions []string
+	if body.IncludeQuestions {
+		questions, ok = h.readRoomQuestions(w, r, roomID)
+		if !ok {
+			return
+		}
+
+		if err := validateQuestions(questions); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	logger.Default.Info(r.Context(), "cloning room", "room_id", rawRoomID, "question_count", len(questions))
+
+	dbCtx, cancel := h.withDatabaseTimeout(r.Context())
+	defer cancel()
+
+	newRoom, err := h.insertRoom(dbCtx, theme, visibility, questions)
+	if err != nil {
+		logger.Default.Error(r.Context(), "failed to clone room", "room_id", rawRoomID, "error", err)
+		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		return
+	}
+
+	logger.Default.Info(r.Context(), "room cloned successfully", "source_room_id", rawRoomID, "room_id", newRoom.ID.String())
+
+	h.sendRoomCreated(w, r, newRoom)
+}
+
+// handleCreateRoomTemplate saves a room configuration as a named template owned by the current session
+func (h apiHandler) handleCreateRoomTemplate(w http.ResponseWriter, r *http.Request) {
+	session, ok := middleware.GetUserSessionFromContext(r.Context())
+	if !ok {
+		responses.SendError(w, http.StatusUnauthorized, "No active session")
+		return
+	}
+
+	type _body struct {
+		Name             string   `json:"name"`
+		Theme            string   `json:"theme"`
+		Questions        []string `json:"questions"`
+		RoomID           string   `json:"room_id"`
+		IncludeQuestions bool     `json:"include_questions"`
+	}
+	var body _body
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		responses.SendError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	body.Name = strings.TrimSpace(body.Name)
+	if body.Name == "" || len(body.Name) > maxTemplateNameLength {
+		responses.SendError(w, http.StatusBadRequest, "Template name is required and must have at most 100 characters")
+		return
+	}
+
+	theme := body.Theme
+	questions := body.Questions
+
+	// Template a partir de uma sala existente
+	if body.RoomID != "" {
+		roomID, err := uuid.Parse(body.RoomID)
+		if err != nil {
+			responses.SendError(w, http.StatusBadRequest, "Invalid room id")
+			return
+		}
+
+		room, err := h.q.GetRoom(r.Context(), roomID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				responses.SendError(w, http.StatusNotFound, "Room not found")
+				return
+			}
+
+			logger.Default.Error(r.Context(), "failed to get room for template", "room_id", body.RoomID, "error", err)
+			responses.SendError(w, http.StatusInternalServerError, "Failed to create template")
+			return
+		}
+
+		// Só o host ou o criador transforma a sala em template, como na exportação
+		isHost, err := h.isRoomHost(r.Context(), roomID, r.Header.Get("X-Host-Token"))
+		if err != nil {
+			logger.Default.Error(r.Context(), "failed to check room host", "room_id", body.RoomID, "error", err)
+			responses.SendError(w, http.StatusInternalServerError, "Failed to create template")
+			return
+		}
+		if !isHost {
+			logger.Default.Warn(r.Context(), "room template denied", "room_id", body.RoomID)
+			responses.SendError(w, http.StatusForbidden, "Only room host or creator can create a template from this room")
+			return
+		}
+
+		if theme == "" {
+			theme = room.Theme
+		}
+
+		if body.IncludeQuestions {
+			roomQuestions, ok := h.readRoomQuestions(w, r, roomID)
+			if !ok {
+				return
+			}
+			questions = append(questions, roomQuestions...)
+		}
+	}
+
+	if theme == "" {
+		responses.SendError(w, http.StatusBadRequest, "Template theme is required")
+		return
+	}
+
+	switch err := validateQuestions(questions); {
+	case errors.Is(err, errTooManyQuestions):
+		responses.SendError(w, http.StatusBadRequest, "Templates can have at most 200 questions")
+		return
+	case err != nil:
+		responses.SendError(w, http.StatusBadRequest, "Questions must not be empty and must have at most 255 characters")
+		return
+	}
+
+	if questions == nil {
+		questions = []string{}
+	}
+
+	template, err := h.q.InsertRoomTemplate(r.Context(), pgstore.InsertRoomTemplateParams{
+		OwnerSessionID: session.ID,
+		Name:           body.Name,
+		Theme:          theme,
+		Questions:      questions,
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			responses.SendError(w, http.StatusConflict, "A template with this name already exists")
+			return
+		}
+
+		logger.Default.Error(r.Context(), "failed to insert room template", "error", err)
+		responses.SendError(w, http.StatusInternalServerError, "Failed to create template")
+		return
+	}
+
+	logger.Default.Info(r.Context(), "room template created", "template_id", template.ID.String(), "question_count", len(questions))
+
+	responses.JSON(w, http.StatusCreated, newRoomTemplateResponse(template))
+}
+
+// handleGetRoomTemplates returns all templates owned by the current session
+func (h apiHandler) handleGetRoomTemplates(w http.ResponseWriter, r *http.Request) {
+	session, ok := middleware.GetUserSessionFromContext(r.Context())
+	if !ok {
+		responses.SendError(w, http.StatusUnauthorized, "No active session")
+		return
+	}
+
+	templates, err := h.q.GetSessionRoomTemplates(r.Context(), session.ID)
+	if err != nil {
+		logger.Default.Error(r.Context(), "failed to get room templates", "error", err)
+		responses.SendError(w, http.StatusInternalServerError, "Failed to get templates")
+		return
+	}
+
+	result := make([]RoomTemplateResponse, 0, len(templates))
+	for _, template := range templates {
+		result = append(result, newRoomTemplateResponse(template))
+	}
+
+	sendJSON(w, result)
+}
+
+// handleDeleteRoomTemplate deletes a template owned by the current session
+func (h apiHandler) handleDeleteRoomTemplate(w http.ResponseWriter, r *http.Request) {
+	session, ok := middleware.GetUserSessionFromContext(r.Context())
+	if !ok {
+		responses.SendError(w, http.StatusUnauthorized, "No active session")
+		return
+	}
+
+	templateID, err := uuid.Parse(chi.URLParam(r, "template_id"))
+	if err != nil {
+		responses.SendError(w, http.StatusBadRequest, "Invalid template id")
+		return
+	}
+
+	rowsAffected, err := h.q.DeleteRoomTemplate(r.Context(), pgstore.DeleteRoomTemplateParams{
+		ID:             templateID,
+		OwnerSessionID: session.ID,
+	})
+	if err != nil {
+		logger.Default.Error(r.Context(), "failed to delete room template", "template_id", templateID.String(), "error", err)
+		responses.SendError(w, http.StatusInternalServerError, "Failed to delete template")
+		return
+	}
+
+	if rowsAffected == 0 {
+		responses.SendError(w, http.StatusNotFound, "Template not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// readRoomTemplate loads a template owned by the current session, writing the error response on failure
+func (h apiHandler) readRoomTemplate(w http.ResponseWriter, r *http.Request, rawTemplateID string) (pgstore.RoomTemplate, bool) {
+	templateID, err := uuid.Parse(rawTemplateID)
+	if err != nil {
+		http.Error(w, "invalid template id", http.StatusBadRequest)
+		return pgstore.RoomTemplate{}, false
+	}
+
+	session, ok := middleware.GetUserSessionFromContext(r.Context())
+	if !ok {
+		http.Error(w, "session required", http.StatusUnauthorized)
+		return pgstore.RoomTemplate{}, false
+	}
+
+	template, err := h.q.GetRoomTemplate(r.Context(), pgstore.GetRoomTemplateParams{
+		ID:             templateID,
+		OwnerSessionID: session.ID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "template not found", http.StatusNotFound)
+			return pgstore.RoomTemplate{}, false
+		}
+
+		logger.Default.Error(r.Context(), "failed to get room template", "template_id", rawTemplateID, "error", err)
+		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		return pgstore.RoomTemplate{}, false
+	}
+
+	return template, true
+}
+
+// readRoomQuestions returns the text of every message in a room, oldest first, writing the
+// error response on failure
+func (h apiHandler) readRoomQuestions(w http.ResponseWriter, r *http.Request, roomID uuid.UUID) ([]string, bool) {
+	messages, err := h.q.GetRoomMessages(r.Context(), roomID)
+	if err != nil {
+		logger.Default.Error(r.Context(), "failed to get room questions", "room_id", roomID.String(), "error", err)
+		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		return nil, false
+	}
+
+	questions := make([]string, 0, len(messages))
+	for _, message := range messages {
+		questions = append(questions, message.Message)
+	}
+
+	return questions, true
+}
+
+// validateQuestions checks the questions a room or a template is created with
+func validateQuestions(questions []string) error {
+	if len(questions) > maxTemplateQuestions {
+		return errTooManyQuestions
+	}
+
+	for _, question := range questions {
+		if strings.TrimSpace(question) == "" || utf8.RuneCountInString(question) > maxMessageLength {
+			return errInvalidQuestion
+		}
+	}
+	return nil
+}
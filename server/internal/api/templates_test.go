@@ -0,0 +1,33 @@
+package api
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateQuestions(t *testing.T) {
+	tooMany := make([]string, maxTemplateQuestions+1)
+	for i := range tooMany {
+		tooMany[i] = "question"
+	}
+
+	for _, tc := range []struct {
+		name      string
+		questions []string
+		want      error
+	}{
+		{name: "none", questions: nil},
+		{name: "valid", questions: []string{"First?", "Second?"}},
+		{name: "multibyte at the limit", questions: []string{strings.Repeat("é", maxMessageLength)}},
+		{name: "too long", questions: []string{strings.Repeat("a", maxMessageLength+1)}, want: errInvalidQuestion},
+		{name: "blank", questions: []string{"ok", "  "}, want: errInvalidQuestion},
+		{name: "too many", questions: tooMany, want: errTooManyQuestions},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := validateQuestions(tc.questions); !errors.Is(err, tc.want) {
+				t.Errorf("validateQuestions = %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
@@ -63,7 +63,7 @@ func (h apiHandler) handleGetUserRooms(w http.ResponseWriter, r *http.Request) {
 		userRooms = append(userRooms, UserRoomResponse{
 			ID:        room.ID.String(),
 			Theme:     room.Theme,
-			CreatedAt: room.CreatedAt.Time.Format("2006-01-02T15:04:05Z"),
+			CreatedAt: formatTimestamp(room.CreatedAt.Time),
 		})
 	}
 
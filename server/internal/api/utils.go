@@ -1,9 +1,11 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
+	"time"
 
 	"github.com/JeanGrijp/ask-me-anything/internal/logger"
 	"github.com/JeanGrijp/ask-me-anything/internal/store/pgstore"
@@ -11,8 +13,15 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
+// formatTimestamp formats a time read from a TIMESTAMP column. The database sessions use UTC
+// (see config.Database.ConnString), so the value is a UTC time.
+func formatTimestamp(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
 func (h apiHandler) readRoom(
 	w http.ResponseWriter,
 	r *http.Request,
@@ -44,3 +53,24 @@ func sendJSON(w http.ResponseWriter, rawData any) {
 	w.Header().Set("Content-Type", "application/json")
 	_, _ = w.Write(data)
 }
+
+// withTx executa fn dentro de uma transação, fazendo commit apenas se fn não retornar erro
+func (h apiHandler) withTx(ctx context.Context, fn func(q *pgstore.Queries) error) error {
+	tx, err := h.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(h.q.WithTx(tx)); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// isUniqueViolation reports whether err is a Postgres unique constraint violation
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
@@ -0,0 +1,346 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/JeanGrijp/ask-me-anything/internal/logger"
+	"github.com/JeanGrijp/ask-me-anything/internal/responses"
+	"github.com/JeanGrijp/ask-me-anything/internal/store/pgstore"
+	"github.com/JeanGrijp/ask-me-anything/internal/webhooks"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	maxWebhookURLLength     = 2048
+	defaultDeliveryLogLimit = 50
+	maxDeliveryLogLimit     = 200
+)
+
+// Eventos que podem ser assinados por webhooks
+var webhookEventKinds = map[string]bool{
+	MessageKindMessageCreated:           true,
+	MessageKindMessageReactionIncreased: true,
+	MessageKindMessageReactionDecreased: true,
+	MessageKindMessageAnswered:          true,
+	MessageKindRoomDeleted:              true,
+	MessageKindMessagesImported:         true,
+	MessageKindMessageSpotlighted:       true,
+	MessageKindPollOpened:               true,
+	MessageKindPollResultsUpdated:       true,
+	MessageKindPollClosed:               true,
+}
+
+// WebhookResponse represents a webhook subscription of a room.
+// Secret is only returned when the subscription is created.
+type WebhookResponse struct {
+	ID        string   `json:"id"`
+	URL       string   `json:"url"`
+	Events    []string `json:"events"`
+	Active    bool     `json:"active"`
+	CreatedAt string   `json:"created_at"`
+	Secret    string   `json:"secret,omitempty"`
+}
+
+// WebhookDeliveryResponse represents one entry of the delivery log of a webhook
+type WebhookDeliveryResponse struct {
+	ID             int64           `json:"id"`
+	EventKind      string          `json:"event_kind"`
+	Status         string          `json:"status"`
+	Attempts       int32           `json:"attempts"`
+	NextAttemptAt  string          `json:"next_attempt_at,omitempty"`
+	LastAttemptAt  string          `json:"last_attempt_at,omitempty"`
+	LastStatusCode *int32          `json:"last_status_code"`
+	LastError      string          `json:"last_error,omitempty"`
+	CreatedAt      string          `json:"created_at"`
+	DeliveredAt    string          `json:"delivered_at,omitempty"`
+	Payload        json.RawMessage `json:"payload"`
+}
+
+func newWebhookResponse(subscription pgstore.WebhookSubscription) WebhookResponse {
+	events := subscription.EventKinds
+	if events == nil {
+		events = []string{}
+	}
+
+	return WebhookResponse{
+		ID:        subscription.ID.String(),
+		URL:       subscription.Url,
+		Events:    events,
+		Active:    subscription.Active,
+		CreatedAt: formatTimestamp(subscription.CreatedAt.Time),
+	}
+}
+
+// handleCreateWebhook subscribes an HTTP endpoint to the events of a room (host only).
+// An empty "events" list subscribes to every event.
+func (h apiHandler) handleCreateWebhook(w http.ResponseWriter, r *http.Request) {
+	_, rawRoomID, roomID, ok := h.readRoom(w, r)
+	if !ok {
+		return
+	}
+
+	type _body struct {
+		URL    string   `json:"url"`
+		Events []string `json:"events"`
+	}
+	var body _body
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		logger.Default.Warn(r.Context(), "invalid JSON in create webhook request", "room_id", rawRoomID, "error", err)
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+
+	if len(body.URL) > maxWebhookURLLength {
+		http.Error(w, "url is too long", http.StatusBadRequest)
+		return
+	}
+
+	// Endereços internos fora das redes liberadas são recusados, para que o webhook não sirva
+	// para sondar a rede do servidor
+	if err := h.dispatcher.ValidateURL(r.Context(), body.URL); err != nil {
+		logger.Default.Warn(r.Context(), "webhook url rejected", "room_id", rawRoomID, "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	events := make([]string, 0, len(body.Events))
+	seen := make(map[string]bool, len(body.Events))
+	for _, kind := range body.Events {
+		if !webhookEventKinds[kind] {
+			http.Error(w, "unknown event kind: "+kind, http.StatusBadRequest)
+			return
+		}
+		if !seen[kind] {
+			seen[kind] = true
+			events = append(events, kind)
+		}
+	}
+
+	secret, err := webhooks.NewSecret()
+	if err != nil {
+		logger.Default.Error(r.Context(), "failed to generate webhook secret", "room_id", rawRoomID, "error", err)
+		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		return
+	}
+
+	subscription, err := h.q.InsertWebhookSubscription(r.Context(), pgstore.InsertWebhookSubscriptionParams{
+		RoomID:     roomID,
+		Url:        body.URL,
+		Secret:     secret,
+		EventKinds: events,
+	})
+	if err != nil {
+		logger.Default.Error(r.Context(), "failed to insert webhook subscription", "room_id", rawRoomID, "error", err)
+		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		return
+	}
+
+	logger.Default.Info(r.Context(), "webhook created", "room_id", rawRoomID, "webhook_id", subscription.ID.String(), "events", len(events))
+
+	response := newWebhookResponse(subscription)
+	response.Secret = subscription.Secret
+
+	responses.JSON(w, http.StatusCreated, response)
+}
+
+// handleGetWebhooks lists the webhook subscriptions of a room (host only)
+func (h apiHandler) handleGetWebhooks(w http.ResponseWriter, r *http.Request) {
+	_, rawRoomID, roomID, ok := h.readRoom(w, r)
+	if !ok {
+		return
+	}
+
+	subscriptions, err := h.q.GetRoomWebhookSubscriptions(r.Context(), roomID)
+	if err != nil {
+		logger.Default.Error(r.Context(), "failed to get webhook subscriptions", "room_id", rawRoomID, "error", err)
+		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		return
+	}
+
+	result := make([]WebhookResponse, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		result = append(result, newWebhookResponse(subscription))
+	}
+
+	sendJSON(w, result)
+}
+
+// handleDeleteWebhook removes a webhook subscription (host only).
+// Deliveries already queued are still sent.
+func (h apiHandler) handleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	_, rawRoomID, roomID, ok := h.readRoom(w, r)
+	if !ok {
+		return
+	}
+
+	rawWebhookID := chi.URLParam(r, "webhook_id")
+	webhookID, err := uuid.Parse(rawWebhookID)
+	if err != nil {
+		http.Error(w, "invalid webhook id", http.StatusBadRequest)
+		return
+	}
+
+	rowsAffected, err := h.q.DeleteWebhookSubscription(r.Context(), pgstore.DeleteWebhookSubscriptionParams{
+		ID:     webhookID,
+		RoomID: roomID,
+	})
+	if err != nil {
+		logger.Default.Error(r.Context(), "failed to delete webhook subscription", "room_id", rawRoomID, "webhook_id", rawWebhookID, "error", err)
+		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		return
+	}
+
+	if rowsAffected == 0 {
+		http.Error(w, "webhook not found", http.StatusNotFound)
+		return
+	}
+
+	logger.Default.Info(r.Context(), "webhook deleted", "room_id", rawRoomID, "webhook_id", rawWebhookID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleGetWebhookDeliveries returns the most recent deliveries of a webhook (host only)
+func (h apiHandler) handleGetWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	subscription, ok := h.readWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	limit := defaultDeliveryLogLimit
+	if rawLimit := r.URL.Query().Get("limit"); rawLimit != "" {
+		parsed, err := strconv.Atoi(rawLimit)
+		if err != nil || parsed < 1 || parsed > maxDeliveryLogLimit {
+			http.Error(w, "limit must be between 1 and 200", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	deliveries, err := h.q.GetWebhookDeliveries(r.Context(), pgstore.GetWebhookDeliveriesParams{
+		SubscriptionID: pgtype.UUID{Bytes: subscription.ID, Valid: true},
+		Limit:          int32(limit),
+	})
+	if err != nil {
+		logger.Default.Error(r.Context(), "failed to get webhook deliveries", "webhook_id", subscription.ID.String(), "error", err)
+		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		return
+	}
+
+	result := make([]WebhookDeliveryResponse, 0, len(deliveries))
+	for _, d := range deliveries {
+		delivery := WebhookDeliveryResponse{
+			ID:            d.ID,
+			EventKind:     d.EventKind,
+			Status:        d.Status,
+			Attempts:      d.Attempts,
+			LastAttemptAt: formatExportTime(d.LastAttemptAt),
+			LastError:     d.LastError.String,
+			CreatedAt:     formatExportTime(d.CreatedAt),
+			DeliveredAt:   formatExportTime(d.DeliveredAt),
+			Payload:       d.Payload,
+		}
+		if d.Status == webhooks.StatusPending {
+			delivery.NextAttemptAt = formatExportTime(d.NextAttemptAt)
+		}
+		if d.LastStatusCode.Valid {
+			delivery.LastStatusCode = &d.LastStatusCode.Int32
+		}
+		result = append(result, delivery)
+	}
+
+	sendJSON(w, result)
+}
+
+// handleRetryWebhookDelivery puts a dead delivery back in the queue (host only)
+func (h apiHandler) handleRetryWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	subscription, ok := h.readWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	rawDeliveryID := chi.URLParam(r, "delivery_id")
+	deliveryID, err := strconv.ParseInt(rawDeliveryID, 10, 64)
+	if err != nil {
+		http.Error(w, "invalid delivery id", http.StatusBadRequest)
+		return
+	}
+
+	rowsAffected, err := h.q.RetryWebhookDelivery(r.Context(), pgstore.RetryWebhookDeliveryParams{
+		ID:             deliveryID,
+		SubscriptionID: pgtype.UUID{Bytes: subscription.ID, Valid: true},
+	})
+	if err != nil {
+		logger.Default.Error(r.Context(), "failed to retry webhook delivery", "delivery_id", rawDeliveryID, "error", err)
+		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		return
+	}
+
+	if rowsAffected == 0 {
+		http.Error(w, "dead delivery not found", http.StatusNotFound)
+		return
+	}
+
+	logger.Default.Info(r.Context(), "webhook delivery requeued", "webhook_id", subscription.ID.String(), "delivery_id", rawDeliveryID)
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// readWebhook loads the webhook of the URL, checking that it belongs to the room
+func (h apiHandler) readWebhook(w http.ResponseWriter, r *http.Request) (pgstore.WebhookSubscription, bool) {
+	_, rawRoomID, roomID, ok := h.readRoom(w, r)
+	if !ok {
+		return pgstore.WebhookSubscription{}, false
+	}
+
+	webhookID, err := uuid.Parse(chi.URLParam(r, "webhook_id"))
+	if err != nil {
+		http.Error(w, "invalid webhook id", http.StatusBadRequest)
+		return pgstore.WebhookSubscription{}, false
+	}
+
+	subscription, err := h.q.GetWebhookSubscription(r.Context(), pgstore.GetWebhookSubscriptionParams{
+		ID:     webhookID,
+		RoomID: roomID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "webhook not found", http.StatusNotFound)
+			return pgstore.WebhookSubscription{}, false
+		}
+
+		logger.Default.Error(r.Context(), "failed to get webhook subscription", "room_id", rawRoomID, "webhook_id", webhookID.String(), "error", err)
+		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		return pgstore.WebhookSubscription{}, false
+	}
+
+	return subscription, true
+}
+
+// enqueueWebhooks queues the event for the webhooks of its room
+func (h apiHandler) enqueueWebhooks(msg Message) {
+	roomID, err := uuid.Parse(msg.RoomID)
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := h.withDatabaseTimeout(context.Background())
+	defer cancel()
+
+	queued, err := webhooks.Enqueue(ctx, h.q, roomID, msg.Kind, msg.Value)
+	if err != nil {
+		logger.Default.Error(ctx, "failed to enqueue webhook deliveries", "room_id", msg.RoomID, "message_kind", msg.Kind, "error", err)
+		return
+	}
+
+	if queued > 0 {
+		logger.Default.Debug(ctx, "webhook deliveries queued", "room_id", msg.RoomID, "message_kind", msg.Kind, "count", queued)
+	}
+}
@@ -1 +1,389 @@
 package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/JeanGrijp/ask-me-anything/internal/logger"
+	"github.com/JeanGrijp/ask-me-anything/internal/middleware"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	CommandPostQuestion = "post_question"
+	CommandReact        = "react"
+	CommandUnreact      = "unreact"
+	CommandPing         = "ping"
+	CommandSubscribe    = "subscribe"
+	CommandUnsubscribe  = "unsubscribe"
+
+	MessageKindCommandAck   = "command_ack"
+	MessageKindCommandError = "command_error"
+
+	// Tamanho máximo de um comando recebido pelo WebSocket
+	maxCommandSize = 16 << 10
+	// Salas que uma mesma conexão pode acompanhar
+	maxRoomsPerConnection = 20
+)
+
+// Códigos de erro devolvidos em command_error
+const (
+	CommandErrorInvalidCommand   = "invalid_command"
+	CommandErrorUnknownCommand   = "unknown_command"
+	CommandErrorInvalidMessageID = "invalid_message_id"
+	CommandErrorSessionRequired  = "session_required"
+	CommandErrorUnknownRoom      = "unknown_room"
+	CommandErrorTooManyRooms     = "too_many_rooms"
+	CommandErrorRoomFull         = "room_full"
+	CommandErrorInvalidHostToken = "invalid_host_token"
+	CommandErrorInternal         = "internal_error"
+)
+
+// Command is a request sent by a WebSocket client. ID is chosen by the client and echoed
+// in the command_ack or command_error reply. RoomID is required on /subscribe and defaults
+// to the room of the connection on /subscribe/{room_id}.
+type Command struct {
+	ID        string `json:"id"`
+	Type      string `json:"type"`
+	RoomID    string `json:"room_id,omitempty"`
+	Message   string `json:"message,omitempty"`
+	MessageID string `json:"message_id,omitempty"`
+	Since     *int64 `json:"since,omitempty"`
+	HostToken string `json:"host_token,omitempty"`
+}
+
+type MessageCommandAck struct {
+	ID     string `json:"id"`
+	Type   string `json:"type"`
+	Result any    `json:"result,omitempty"`
+}
+
+type MessageCommandError struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// commandError is a rejection reported to the client as command_error
+type commandError struct {
+	code    string
+	message string
+}
+
+func (e *commandError) Error() string {
+	return e.message
+}
+
+// wsConnection is the state of one WebSocket connection. It is only used by the read loop.
+type wsConnection struct {
+	client *client
+	// defaultRoom is the room of /subscribe/{room_id}, used by commands without room_id
+	defaultRoom string
+	// rooms are the rooms the connection may act on, validated when first subscribed
+	rooms map[string]*wsRoom
+}
+
+type wsRoom struct {
+	id         uuid.UUID
+	isHost     bool
+	subscribed bool
+}
+
+func newWSConnection(c *client) *wsConnection {
+	return &wsConnection{client: c, rooms: make(map[string]*wsRoom)}
+}
+
+// room returns the room a command refers to
+func (s *wsConnection) room(rawRoomID string) (string, *wsRoom, error) {
+	if rawRoomID == "" {
+		rawRoomID = s.defaultRoom
+	}
+	if rawRoomID == "" {
+		return "", nil, &commandError{code: CommandErrorInvalidCommand, message: "room_id is required"}
+	}
+
+	room, ok := s.rooms[rawRoomID]
+	if !ok {
+		return rawRoomID, nil, &commandError{code: CommandErrorUnknownRoom, message: "room is not subscribed on this connection"}
+	}
+	return rawRoomID, room, nil
+}
+
+// checkOrigin accepts WebSocket upgrades from the allowed origins only. Requests without
+// an Origin header do not come from a browser and are accepted, as in the default upgrader.
+func checkOrigin(allowedOrigins []string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+
+		for _, allowed := range allowedOrigins {
+			if allowed == "*" || strings.EqualFold(allowed, origin) {
+				return true
+			}
+		}
+
+		logger.Default.Warn(r.Context(), "websocket origin not allowed", "origin", origin, "path", r.URL.Path)
+		return false
+	}
+}
+
+// userSessionContext resolves the user_session cookie for routes served outside the chi
+// router, where UserSessionMiddleware does not run. Unlike the middleware, it never creates
+// a session: without a valid cookie the connection is anonymous.
+func (h apiHandler) userSessionContext(r *http.Request) context.Context {
+	token, err := h.userSessionMgr.GetSessionFromRequest(r)
+	if err != nil {
+		return r.Context()
+	}
+
+	session, err := h.userSessionMgr.GetSession(r, token)
+	if err != nil {
+		logger.Default.Debug(r.Context(), "ignoring invalid user session", "error", err)
+		return r.Context()
+	}
+
+	return context.WithValue(r.Context(), middleware.UserSessionContextKey, session)
+}
+
+// serveWebSocket reads the commands of the connection and blocks until it ends
+func (h apiHandler) serveWebSocket(ctx context.Context, conn *websocket.Conn, state *wsConnection) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Configurar timeouts e handlers para manter conexão viva
+	conn.SetReadDeadline(time.Now().Add(h.timeouts.WebSocketRead))
+	conn.SetPongHandler(func(string) error {
+		conn.SetReadDeadline(time.Now().Add(h.timeouts.WebSocketRead))
+		return nil
+	})
+
+	// A leitura processa pongs, o fechamento pelo cliente e os comandos; a escrita é feita só por c.run
+	go func() {
+		defer cancel()
+		h.readCommands(ctx, conn, state)
+	}()
+
+	h.serveClient(ctx, state.client)
+}
+
+// readCommands reads the frames sent by the client until the connection fails or closes.
+// Every command is answered, in order, through the client's writer goroutine.
+func (h apiHandler) readCommands(ctx context.Context, conn *websocket.Conn, state *wsConnection) {
+	conn.SetReadLimit(maxCommandSize)
+
+	for {
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				logger.Default.Debug(ctx, "websocket read failed", "error", err)
+			}
+			return
+		}
+
+		var cmd Command
+		if err := decodeCommand(messageType, data, &cmd); err != nil || cmd.Type == "" {
+			if !h.replyCommand(ctx, state.client, cmd, nil, &commandError{code: CommandErrorInvalidCommand, message: "invalid command"}) {
+				return
+			}
+			continue
+		}
+
+		logger.Default.Debug(ctx, "websocket command received", "room_id", cmd.RoomID, "command", cmd.Type, "command_id", cmd.ID)
+
+		result, err := h.runCommand(ctx, state, cmd)
+		if !h.replyCommand(ctx, state.client, cmd, result, err) {
+			return
+		}
+	}
+}
+
+// runCommand executes cmd with the same validation and authorization as the REST handlers
+func (h apiHandler) runCommand(ctx context.Context, state *wsConnection, cmd Command) (any, error) {
+	switch cmd.Type {
+	case CommandPing:
+		return nil, nil
+
+	case CommandSubscribe:
+		return h.subscribeCommand(ctx, state, cmd)
+
+	case CommandUnsubscribe:
+		rawRoomID, room, err := state.room(cmd.RoomID)
+		if err != nil {
+			return nil, err
+		}
+
+		if room.subscribed {
+			h.leaveRoom(rawRoomID, state.client)
+			room.subscribed = false
+		}
+		if rawRoomID != state.defaultRoom {
+			delete(state.rooms, rawRoomID)
+		}
+		return commandRoomResult{RoomID: rawRoomID}, nil
+
+	case CommandPostQuestion:
+		rawRoomID, room, err := state.room(cmd.RoomID)
+		if err != nil {
+			return nil, err
+		}
+
+		dbCtx, cancel := h.withDatabaseTimeout(ctx)
+		defer cancel()
+
+		messageID, err := h.createRoomMessage(dbCtx, rawRoomID, room.id, cmd.Message)
+		if err != nil {
+			return nil, err
+		}
+
+		type result struct {
+			ID string `json:"id"`
+		}
+		return result{ID: messageID.String()}, nil
+
+	case CommandReact, CommandUnreact:
+		rawRoomID, room, err := state.room(cmd.RoomID)
+		if err != nil {
+			return nil, err
+		}
+
+		messageID, err := uuid.Parse(cmd.MessageID)
+		if err != nil {
+			return nil, &commandError{code: CommandErrorInvalidMessageID, message: "invalid message id"}
+		}
+
+		dbCtx, cancel := h.withDatabaseTimeout(ctx)
+		defer cancel()
+
+		var count int64
+		if cmd.Type == CommandReact {
+			count, err = h.reactToMessage(dbCtx, rawRoomID, room.id, messageID)
+		} else {
+			count, err = h.removeReaction(dbCtx, rawRoomID, messageID)
+		}
+		if errors.Is(err, errSessionRequired) {
+			return nil, &commandError{code: CommandErrorSessionRequired, message: "session required"}
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		type result struct {
+			Count int64 `json:"count"`
+		}
+		return result{Count: count}, nil
+	}
+
+	return nil, &commandError{code: CommandErrorUnknownCommand, message: "unknown command"}
+}
+
+type commandRoomResult struct {
+	RoomID string `json:"room_id"`
+}
+
+// subscribeCommand adds a room to the connection, or registers it again after an unsubscribe.
+// Like ?since= on connect, since replays the events the client missed. A host token sent with
+// the command gives the connection the host role in that room.
+func (h apiHandler) subscribeCommand(ctx context.Context, state *wsConnection, cmd Command) (any, error) {
+	rawRoomID := cmd.RoomID
+	if rawRoomID == "" {
+		rawRoomID = state.defaultRoom
+	}
+	if rawRoomID == "" {
+		return nil, &commandError{code: CommandErrorInvalidCommand, message: "room_id is required"}
+	}
+
+	room, known := state.rooms[rawRoomID]
+	if !known {
+		if len(state.rooms) >= maxRoomsPerConnection {
+			return nil, &commandError{code: CommandErrorTooManyRooms, message: "too many rooms on this connection"}
+		}
+
+		roomID, err := uuid.Parse(rawRoomID)
+		if err != nil {
+			return nil, &commandError{code: CommandErrorUnknownRoom, message: "invalid room id"}
+		}
+
+		dbCtx, cancel := h.withDatabaseTimeout(ctx)
+		defer cancel()
+
+		if _, err := h.q.GetRoom(dbCtx, roomID); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil, &commandError{code: CommandErrorUnknownRoom, message: "room not found"}
+			}
+			return nil, err
+		}
+
+		isHost, err := h.isRoomHost(dbCtx, roomID, "")
+		if err != nil {
+			return nil, err
+		}
+
+		room = &wsRoom{id: roomID, isHost: isHost}
+	}
+
+	if cmd.HostToken != "" {
+		if !h.sessionMgr.IsRoomHost(room.id, cmd.HostToken) {
+			return nil, &commandError{code: CommandErrorInvalidHostToken, message: "invalid host token"}
+		}
+		room.isHost = true
+	}
+
+	if !room.subscribed {
+		if _, err := h.joinRoom(ctx, state.client, rawRoomID, cmd.Since, room.isHost); err != nil {
+			if _, limited := limitCloseReason(err); limited {
+				return nil, &commandError{code: CommandErrorRoomFull, message: err.Error()}
+			}
+			return nil, err
+		}
+		room.subscribed = true
+	}
+
+	state.rooms[rawRoomID] = room
+
+	return commandRoomResult{RoomID: rawRoomID}, nil
+}
+
+// replyCommand sends the command_ack or command_error for cmd. It returns false when the
+// client can no longer receive messages.
+func (h apiHandler) replyCommand(ctx context.Context, c *client, cmd Command, result any, err error) bool {
+	msg := Message{
+		Kind:   MessageKindCommandAck,
+		Value:  MessageCommandAck{ID: cmd.ID, Type: cmd.Type, Result: result},
+		RoomID: cmd.RoomID,
+	}
+
+	if err != nil {
+		var cmdErr *commandError
+		if !errors.As(err, &cmdErr) {
+			logger.Default.Error(ctx, "websocket command failed", "command", cmd.Type, "command_id", cmd.ID, "error", err)
+			cmdErr = &commandError{code: CommandErrorInternal, message: "something went wrong"}
+		}
+
+		msg = Message{
+			Kind:   MessageKindCommandError,
+			RoomID: cmd.RoomID,
+			Value: MessageCommandError{
+				ID:      cmd.ID,
+				Type:    cmd.Type,
+				Code:    cmdErr.code,
+				Message: cmdErr.message,
+			},
+		}
+	}
+
+	event, err := encodeEvent(msg)
+	if err != nil {
+		logger.Default.Error(ctx, "failed to encode command reply", "command", cmd.Type, "error", err)
+		return true
+	}
+
+	return c.send(event)
+}
@@ -54,11 +54,7 @@ func HostOnlyMiddleware(sm *SessionManager) func(http.Handler) http.Handler {
 
 			logger.Default.Info(ctx, "host action authorized", "room_id", rawRoomID)
 
-			// Adicionar informações no contexto
-			ctx = context.WithValue(ctx, HostTokenKey, token)
-			ctx = context.WithValue(ctx, IsHostKey, true)
-
-			next.ServeHTTP(w, r.WithContext(ctx))
+			next.ServeHTTP(w, r.WithContext(WithHost(ctx, token, true)))
 		})
 	}
 }
@@ -80,15 +76,17 @@ func OptionalHostMiddleware(sm *SessionManager) func(http.Handler) http.Handler
 				}
 			}
 
-			// Adicionar informações no contexto
-			ctx = context.WithValue(ctx, HostTokenKey, token)
-			ctx = context.WithValue(ctx, IsHostKey, isHost)
-
-			next.ServeHTTP(w, r.WithContext(ctx))
+			next.ServeHTTP(w, r.WithContext(WithHost(ctx, token, isHost)))
 		})
 	}
 }
 
+// WithHost adiciona ao contexto o token de host e se ele é válido para a sala
+func WithHost(ctx context.Context, token string, isHost bool) context.Context {
+	ctx = context.WithValue(ctx, HostTokenKey, token)
+	return context.WithValue(ctx, IsHostKey, isHost)
+}
+
 // IsHost verifica se o contexto atual representa um host
 func IsHost(ctx context.Context) bool {
 	isHost, ok := ctx.Value(IsHostKey).(bool)
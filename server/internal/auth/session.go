@@ -4,6 +4,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/JeanGrijp/ask-me-anything/internal/config"
 	"github.com/google/uuid"
 )
 
@@ -20,13 +21,21 @@ type SessionManager struct {
 	sessions  map[string]*HostSession // token -> session
 	roomHosts map[string]string       // room_id -> token
 	mu        sync.RWMutex
+
+	// duration é a validade de cada token de host
+	duration time.Duration
+
+	stop     chan struct{}
+	stopOnce sync.Once
 }
 
 // NewSessionManager cria um novo gerenciador de sessões
-func NewSessionManager() *SessionManager {
+func NewSessionManager(cfg config.Sessions) *SessionManager {
 	sm := &SessionManager{
 		sessions:  make(map[string]*HostSession),
 		roomHosts: make(map[string]string),
+		duration:  cfg.Host,
+		stop:      make(chan struct{}),
 	}
 
 	// Limpeza automática de sessões expiradas (a cada 30 minutos)
@@ -47,7 +56,7 @@ func (sm *SessionManager) CreateHostSession(roomID uuid.UUID) *HostSession {
 		RoomID:    roomID,
 		Token:     token,
 		CreatedAt: now,
-		ExpiresAt: now.Add(24 * time.Hour), // Token válido por 24 horas
+		ExpiresAt: now.Add(sm.duration),
 	}
 
 	sm.sessions[token] = session
@@ -123,7 +132,13 @@ func (sm *SessionManager) cleanupExpiredSessions() {
 	ticker := time.NewTicker(30 * time.Minute)
 	defer ticker.Stop()
 
-	for range ticker.C {
+	for {
+		select {
+		case <-sm.stop:
+			return
+		case <-ticker.C:
+		}
+
 		sm.mu.Lock()
 		now := time.Now()
 
@@ -144,6 +159,11 @@ func (sm *SessionManager) cleanupExpiredSessions() {
 	}
 }
 
+// Stop encerra a limpeza automática de sessões expiradas
+func (sm *SessionManager) Stop() {
+	sm.stopOnce.Do(func() { close(sm.stop) })
+}
+
 // GetSessionInfo retorna informações sobre uma sessão
 func (sm *SessionManager) GetSessionInfo(token string) (*HostSession, bool) {
 	sm.mu.RLock()
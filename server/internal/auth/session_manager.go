@@ -7,21 +7,21 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/JeanGrijp/ask-me-anything/internal/config"
 	"github.com/JeanGrijp/ask-me-anything/internal/store/pgstore"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-const (
-	UserSessionCookieName = "user_session"
-	UserSessionDuration   = 24 * time.Hour
-)
+const UserSessionCookieName = "user_session"
 
 type UserSessionManager struct {
 	store *pgstore.Queries
+	// duration is how long a session lasts without activity
+	duration time.Duration
 }
 
-func NewUserSessionManager(store *pgstore.Queries) *UserSessionManager {
-	return &UserSessionManager{store: store}
+func NewUserSessionManager(store *pgstore.Queries, cfg config.Sessions) *UserSessionManager {
+	return &UserSessionManager{store: store, duration: cfg.User}
 }
 
 // generateSessionToken generates a cryptographically secure random session token
@@ -40,7 +40,7 @@ func (usm *UserSessionManager) CreateSession(r *http.Request) (string, error) {
 		return "", fmt.Errorf("failed to generate session token: %w", err)
 	}
 
-	expiresAt := time.Now().Add(UserSessionDuration)
+	expiresAt := time.Now().Add(usm.duration)
 	userAgent := r.Header.Get("User-Agent")
 
 	_, err = usm.store.CreateUserSession(r.Context(), pgstore.CreateUserSessionParams{
@@ -64,7 +64,7 @@ func (usm *UserSessionManager) GetSession(r *http.Request, token string) (*pgsto
 	}
 
 	// Update last activity and extend expiration
-	newExpiresAt := time.Now().Add(UserSessionDuration)
+	newExpiresAt := time.Now().Add(usm.duration)
 	err = usm.store.UpdateSessionActivity(r.Context(), pgstore.UpdateSessionActivityParams{
 		SessionToken: token,
 		ExpiresAt:    pgtype.Timestamp{Time: newExpiresAt, Valid: true},
@@ -90,7 +90,7 @@ func (usm *UserSessionManager) SetSessionCookie(w http.ResponseWriter, token str
 		Name:     UserSessionCookieName,
 		Value:    token,
 		Path:     "/",
-		MaxAge:   int(UserSessionDuration.Seconds()),
+		MaxAge:   int(usm.duration.Seconds()),
 		HttpOnly: true,
 		Secure:   false, // Set to true in production with HTTPS
 		SameSite: http.SameSiteLaxMode,
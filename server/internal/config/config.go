@@ -0,0 +1,252 @@
+// Package config holds the configuration of the server. It is loaded once at startup from
+// defaults, an optional YAML file, environment variables and flags, in increasing order of
+// precedence, and validated before anything else starts.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the effective configuration of the server
+type Config struct {
+	Server   Server   `yaml:"server"`
+	Database Database `yaml:"database"`
+	Realtime Realtime `yaml:"realtime"`
+	Sessions Sessions `yaml:"sessions"`
+	Timeouts Timeouts `yaml:"timeouts"`
+	Webhooks Webhooks `yaml:"webhooks"`
+	Log      Log      `yaml:"log"`
+}
+
+type Server struct {
+	// Addr is the address the HTTP server listens on
+	Addr string `yaml:"addr" env:"WSRS_ADDR"`
+	// AllowedOrigins restricts both CORS and WebSocket upgrades; "*" accepts any origin
+	AllowedOrigins []string `yaml:"allowed_origins" env:"WSRS_ALLOWED_ORIGINS"`
+}
+
+type Database struct {
+	Host     string `yaml:"host" env:"WSRS_DATABASE_HOST"`
+	Port     int    `yaml:"port" env:"WSRS_DATABASE_PORT"`
+	User     string `yaml:"user" env:"WSRS_DATABASE_USER"`
+	Password string `yaml:"password" env:"WSRS_DATABASE_PASSWORD"`
+	Name     string `yaml:"name" env:"WSRS_DATABASE_NAME"`
+}
+
+type Realtime struct {
+	// Broadcaster is "memory" for a single instance or "postgres" to share events between instances
+	Broadcaster string           `yaml:"broadcaster" env:"WSRS_BROADCASTER"`
+	Limits      ConnectionLimits `yaml:"limits"`
+}
+
+// ConnectionLimits caps the realtime connections, WebSocket and SSE, that one instance
+// accepts. A zero cap is disabled.
+type ConnectionLimits struct {
+	// PerRoom caps the clients registered in a room; multiplexed connections count once per room
+	PerRoom int `yaml:"per_room" json:"per_room" env:"WSRS_MAX_CONNECTIONS_PER_ROOM"`
+	// PerSession caps the connections of a user session; anonymous connections are not counted
+	PerSession int `yaml:"per_session" json:"per_session" env:"WSRS_MAX_CONNECTIONS_PER_SESSION"`
+	// PerIP caps the connections of a client address. The address is the TCP peer, so behind a
+	// proxy or NAT it is shared by many viewers; disabled by default.
+	PerIP int `yaml:"per_ip" json:"per_ip" env:"WSRS_MAX_CONNECTIONS_PER_IP"`
+}
+
+type Sessions struct {
+	// User is how long a user session lasts without activity
+	User time.Duration `yaml:"user" env:"WSRS_USER_SESSION_DURATION"`
+	// Host is how long a host token stays valid
+	Host time.Duration `yaml:"host" env:"WSRS_HOST_SESSION_DURATION"`
+}
+
+// WebSocketPingInterval is how often idle WebSocket connections are pinged
+const WebSocketPingInterval = 30 * time.Second
+
+type Timeouts struct {
+	// Request bounds the REST requests; streaming routes are not affected
+	Request time.Duration `yaml:"request" env:"WSRS_REQUEST_TIMEOUT"`
+	// Database bounds each database operation
+	Database time.Duration `yaml:"database" env:"WSRS_DATABASE_TIMEOUT"`
+	// WebSocketRead is how long a WebSocket connection may stay silent, pongs included.
+	// It must be longer than WebSocketPingInterval.
+	WebSocketRead time.Duration `yaml:"websocket_read" env:"WSRS_WEBSOCKET_READ_TIMEOUT"`
+	// WebSocketWrite bounds each write to a WebSocket connection
+	WebSocketWrite time.Duration `yaml:"websocket_write" env:"WSRS_WEBSOCKET_WRITE_TIMEOUT"`
+	// ClientNotification bounds each write to an SSE stream
+	ClientNotification time.Duration `yaml:"client_notification" env:"WSRS_CLIENT_NOTIFICATION_TIMEOUT"`
+	// Shutdown is how long the server waits for requests and realtime clients when stopping
+	Shutdown time.Duration `yaml:"shutdown" env:"WSRS_SHUTDOWN_TIMEOUT"`
+}
+
+type Webhooks struct {
+	// AllowedCIDRs are the networks webhooks may be delivered to besides public addresses, such
+	// as the network of internal tools or 127.0.0.0/8 for a local receiver. Other loopback,
+	// private and link-local addresses are refused.
+	AllowedCIDRs []string `yaml:"allowed_cidrs" env:"WSRS_WEBHOOK_ALLOWED_CIDRS"`
+}
+
+type Log struct {
+	// Level is debug, info, warn or error
+	Level string `yaml:"level" env:"LOG_LEVEL"`
+	// File receives the JSON logs, rotated; empty logs to stdout only
+	File string `yaml:"file" env:"WSRS_LOG_FILE"`
+}
+
+// Default returns the configuration used when nothing is set
+func Default() Config {
+	return Config{
+		Server: Server{
+			Addr:           ":8080",
+			AllowedOrigins: []string{"http://localhost:3000", "http://localhost:8080", "http://127.0.0.1:3000", "http://127.0.0.1:8080"},
+		},
+		Database: Database{
+			Host: "localhost",
+			Port: 5432,
+		},
+		Realtime: Realtime{
+			Broadcaster: "memory",
+			// Sem limite por sala nem por endereço: atrás de um proxy ou NAT todos os
+			// espectadores chegam do mesmo IP, e uma sala grande passa de milhares de conexões
+			Limits: ConnectionLimits{PerSession: 20},
+		},
+		Sessions: Sessions{
+			User: 24 * time.Hour,
+			Host: 24 * time.Hour,
+		},
+		Timeouts: Timeouts{
+			Request:            30 * time.Second,
+			Database:           5 * time.Second,
+			WebSocketRead:      2 * WebSocketPingInterval, // o prazo que as conexões já usavam: tolera um pong atrasado
+			WebSocketWrite:     10 * time.Second,
+			ClientNotification: 2 * time.Second,
+			Shutdown:           30 * time.Second,
+		},
+		Log: Log{
+			Level: "info",
+			File:  "./internal/logger/logs/api.log",
+		},
+	}
+}
+
+// Validate reports every invalid setting at once
+func (c Config) Validate() error {
+	var errs []error
+	invalid := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if _, _, err := net.SplitHostPort(c.Server.Addr); err != nil {
+		invalid("server.addr: %v", err)
+	}
+	if len(c.Server.AllowedOrigins) == 0 {
+		invalid("server.allowed_origins: at least one origin is required")
+	}
+
+	if c.Database.Host == "" {
+		invalid("database.host is required")
+	}
+	if c.Database.Port < 1 || c.Database.Port > 65535 {
+		invalid("database.port: %d is not a valid port", c.Database.Port)
+	}
+	if c.Database.User == "" {
+		invalid("database.user is required")
+	}
+	if c.Database.Name == "" {
+		invalid("database.name is required")
+	}
+
+	if c.Realtime.Broadcaster != "memory" && c.Realtime.Broadcaster != "postgres" {
+		invalid("realtime.broadcaster: %q must be memory or postgres", c.Realtime.Broadcaster)
+	}
+	limits := c.Realtime.Limits
+	if limits.PerRoom < 0 || limits.PerSession < 0 || limits.PerIP < 0 {
+		invalid("realtime.limits cannot be negative; 0 disables a limit")
+	}
+
+	durations := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"sessions.user", c.Sessions.User},
+		{"sessions.host", c.Sessions.Host},
+		{"timeouts.request", c.Timeouts.Request},
+		{"timeouts.database", c.Timeouts.Database},
+		{"timeouts.websocket_read", c.Timeouts.WebSocketRead},
+		{"timeouts.websocket_write", c.Timeouts.WebSocketWrite},
+		{"timeouts.client_notification", c.Timeouts.ClientNotification},
+		{"timeouts.shutdown", c.Timeouts.Shutdown},
+	}
+	for _, d := range durations {
+		if d.value <= 0 {
+			invalid("%s must be positive", d.name)
+		}
+	}
+
+	if c.Timeouts.WebSocketRead > 0 && c.Timeouts.WebSocketRead <= WebSocketPingInterval {
+		invalid("timeouts.websocket_read must be longer than the %s ping interval", WebSocketPingInterval)
+	}
+
+	for _, cidr := range c.Webhooks.AllowedCIDRs {
+		if _, err := netip.ParsePrefix(cidr); err != nil {
+			invalid("webhooks.allowed_cidrs: %q is not a CIDR such as 10.0.0.0/8", cidr)
+		}
+	}
+
+	if !slices.Contains([]string{"debug", "info", "warn", "error"}, c.Log.Level) {
+		invalid("log.level: %q must be debug, info, warn or error", c.Log.Level)
+	}
+
+	return errors.Join(errs...)
+}
+
+// AllowedPrefixes returns the parsed AllowedCIDRs; invalid ones are rejected by Validate
+func (w Webhooks) AllowedPrefixes() []netip.Prefix {
+	prefixes := make([]netip.Prefix, 0, len(w.AllowedCIDRs))
+	for _, cidr := range w.AllowedCIDRs {
+		if prefix, err := netip.ParsePrefix(cidr); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+		}
+	}
+	return prefixes
+}
+
+// ConnString returns the connection string of the database, in the key=value format of pgx.
+// Sessions use UTC, so the TIMESTAMP columns filled with NOW() hold UTC times, whatever the
+// time zone of the database server.
+func (d Database) ConnString() string {
+	return fmt.Sprintf("user=%s password=%s host=%s port=%d dbname=%s timezone=UTC",
+		quote(d.User), quote(d.Password), quote(d.Host), d.Port, quote(d.Name))
+}
+
+// quote escapes a value of a key=value connection string
+func quote(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+	return "'" + value + "'"
+}
+
+// Redacted returns a copy of the configuration safe to print: secrets are masked
+func (c Config) Redacted() Config {
+	if c.Database.Password != "" {
+		c.Database.Password = "REDACTED"
+	}
+	c.Server.AllowedOrigins = slices.Clone(c.Server.AllowedOrigins)
+	return c
+}
+
+// String returns the redacted configuration as YAML, in the format of the configuration file
+func (c Config) String() string {
+	out, err := yaml.Marshal(c.Redacted())
+	if err != nil {
+		return "invalid configuration: " + strconv.Quote(err.Error())
+	}
+	return string(out)
+}
@@ -0,0 +1,162 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Load builds the configuration from, in increasing order of precedence: the defaults, the
+// YAML file given by -config or WSRS_CONFIG, the environment variables named in the env tags
+// and the command line flags in args. The result is validated.
+func Load(args []string) (Config, error) {
+	cfg := Default()
+
+	fs := flag.NewFlagSet("wsrs", flag.ContinueOnError)
+	configPath := fs.String("config", os.Getenv("WSRS_CONFIG"), "path of a YAML configuration file")
+	overrides := registerFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return cfg, err
+	}
+
+	if *configPath != "" {
+		if err := loadFile(&cfg, *configPath); err != nil {
+			return cfg, err
+		}
+	}
+
+	if err := loadEnv(reflect.ValueOf(&cfg).Elem()); err != nil {
+		return cfg, err
+	}
+
+	// Apenas as flags passadas explicitamente sobrescrevem o arquivo e o ambiente
+	var errs []error
+	fs.Visit(func(f *flag.Flag) {
+		if setting, ok := overrides[f.Name]; ok {
+			if err := setValue(reflect.ValueOf(setting(&cfg)).Elem(), f.Value.String()); err != nil {
+				errs = append(errs, fmt.Errorf("-%s: %w", f.Name, err))
+			}
+		}
+	})
+	if err := errors.Join(errs...); err != nil {
+		return cfg, err
+	}
+
+	return cfg, cfg.Validate()
+}
+
+// registerFlags declares the flags of the most common settings. Their defaults are only shown
+// in the usage: a flag not passed leaves the setting to the file and the environment.
+func registerFlags(fs *flag.FlagSet) map[string]func(*Config) any {
+	defaults := Default()
+
+	fs.String("addr", defaults.Server.Addr, "address the HTTP server listens on")
+	fs.String("allowed-origins", strings.Join(defaults.Server.AllowedOrigins, ","), "comma-separated origins allowed by CORS and WebSocket")
+	fs.String("broadcaster", defaults.Realtime.Broadcaster, "memory or postgres")
+	fs.String("database-host", defaults.Database.Host, "database host")
+	fs.Int("database-port", defaults.Database.Port, "database port")
+	fs.String("database-name", defaults.Database.Name, "database name")
+	fs.String("log-level", defaults.Log.Level, "debug, info, warn or error")
+	fs.String("log-file", defaults.Log.File, "rotated JSON log file; empty logs to stdout only")
+	fs.Duration("shutdown-timeout", defaults.Timeouts.Shutdown, "how long to wait for clients when stopping")
+
+	return map[string]func(*Config) any{
+		"addr":             func(c *Config) any { return &c.Server.Addr },
+		"allowed-origins":  func(c *Config) any { return &c.Server.AllowedOrigins },
+		"broadcaster":      func(c *Config) any { return &c.Realtime.Broadcaster },
+		"database-host":    func(c *Config) any { return &c.Database.Host },
+		"database-port":    func(c *Config) any { return &c.Database.Port },
+		"database-name":    func(c *Config) any { return &c.Database.Name },
+		"log-level":        func(c *Config) any { return &c.Log.Level },
+		"log-file":         func(c *Config) any { return &c.Log.File },
+		"shutdown-timeout": func(c *Config) any { return &c.Timeouts.Shutdown },
+	}
+}
+
+// loadFile merges a YAML file into cfg; settings missing from the file keep their value.
+// Unknown keys are rejected, so a typo does not silently fall back to the default.
+func loadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// loadEnv sets every field with an env tag whose variable is set and not empty
+func loadEnv(v reflect.Value) error {
+	var errs []error
+
+	for i := range v.NumField() {
+		field := v.Field(i)
+		tag := v.Type().Field(i)
+
+		if field.Kind() == reflect.Struct {
+			if err := loadEnv(field); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+
+		name := tag.Tag.Get("env")
+		if name == "" {
+			continue
+		}
+		raw := os.Getenv(name)
+		if raw == "" {
+			continue
+		}
+
+		if err := setValue(field, raw); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// setValue parses raw into a setting. Lists are comma-separated.
+func setValue(field reflect.Value, raw string) error {
+	switch field.Interface().(type) {
+	case string:
+		field.SetString(raw)
+	case int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", raw)
+		}
+		field.SetInt(int64(n))
+	case time.Duration:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration such as 30s or 5m", raw)
+		}
+		field.SetInt(int64(d))
+	case []string:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported setting type %s", field.Type())
+	}
+	return nil
+}
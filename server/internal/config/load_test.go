@@ -0,0 +1,158 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// clearEnv empties the variables of the environment that Load reads, for the duration of the test
+func clearEnv(t *testing.T) {
+	t.Helper()
+	for _, entry := range os.Environ() {
+		name, _, _ := strings.Cut(entry, "=")
+		if strings.HasPrefix(name, "WSRS_") || name == "LOG_LEVEL" {
+			t.Setenv(name, "")
+		}
+	}
+}
+
+// writeConfigFile writes a YAML configuration file and returns its path
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const testConfigFile = `
+server:
+  addr: ":7000"
+database:
+  user: file-user
+  name: file-db
+  port: 6000
+realtime:
+  broadcaster: postgres
+log:
+  level: warn
+`
+
+func TestLoadPrecedence(t *testing.T) {
+	clearEnv(t)
+	path := writeConfigFile(t, testConfigFile)
+	t.Setenv("WSRS_DATABASE_PORT", "6001")
+	t.Setenv("LOG_LEVEL", "error")
+	t.Setenv("WSRS_ALLOWED_ORIGINS", "https://env.example, https://env2.example")
+
+	cfg, err := Load([]string{"-config", path, "-log-level", "debug"})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	for _, tc := range []struct {
+		setting   string
+		got, want any
+	}{
+		// Só o arquivo define
+		{"server.addr", cfg.Server.Addr, ":7000"},
+		{"database.user", cfg.Database.User, "file-user"},
+		{"realtime.broadcaster", cfg.Realtime.Broadcaster, "postgres"},
+		// O ambiente sobrescreve o arquivo
+		{"database.port", cfg.Database.Port, 6001},
+		// A flag sobrescreve o ambiente e o arquivo
+		{"log.level", cfg.Log.Level, "debug"},
+		// Ninguém define: fica o padrão
+		{"database.host", cfg.Database.Host, Default().Database.Host},
+		{"timeouts.shutdown", cfg.Timeouts.Shutdown, Default().Timeouts.Shutdown},
+	} {
+		if tc.got != tc.want {
+			t.Errorf("%s = %v, want %v", tc.setting, tc.got, tc.want)
+		}
+	}
+
+	if want := []string{"https://env.example", "https://env2.example"}; !slices.Equal(cfg.Server.AllowedOrigins, want) {
+		t.Errorf("server.allowed_origins = %v, want %v", cfg.Server.AllowedOrigins, want)
+	}
+}
+
+func TestLoadFlagsNotPassedKeepFileValues(t *testing.T) {
+	clearEnv(t)
+	path := writeConfigFile(t, testConfigFile)
+	t.Setenv("WSRS_CONFIG", path)
+
+	// -config vem do ambiente; as flags com padrão não passadas não contam
+	cfg, err := Load([]string{"-shutdown-timeout", "5s"})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if cfg.Log.Level != "warn" {
+		t.Errorf("log.level = %q, want the file's warn", cfg.Log.Level)
+	}
+	if cfg.Timeouts.Shutdown != 5*time.Second {
+		t.Errorf("timeouts.shutdown = %s, want 5s", cfg.Timeouts.Shutdown)
+	}
+}
+
+func TestLoadRejectsUnknownKeysAndInvalidValues(t *testing.T) {
+	clearEnv(t)
+
+	path := writeConfigFile(t, "database:\n  user: u\n  name: n\n  hots: typo\n")
+	if _, err := Load([]string{"-config", path}); err == nil || !strings.Contains(err.Error(), "hots") {
+		t.Errorf("unknown key: err = %v, want it named", err)
+	}
+
+	path = writeConfigFile(t, "database:\n  user: u\n  name: n\n")
+	t.Setenv("WSRS_DATABASE_TIMEOUT", "soon")
+	if _, err := Load([]string{"-config", path}); err == nil || !strings.Contains(err.Error(), "WSRS_DATABASE_TIMEOUT") {
+		t.Errorf("invalid env duration: err = %v, want the variable named", err)
+	}
+}
+
+func TestLoadValidates(t *testing.T) {
+	clearEnv(t)
+
+	// Sem usuário e banco a configuração é inválida
+	if _, err := Load(nil); err == nil {
+		t.Fatal("Load accepted a configuration without database user and name")
+	}
+
+	// O ping precisa chegar antes de a leitura expirar
+	t.Setenv("WSRS_DATABASE_USER", "u")
+	t.Setenv("WSRS_DATABASE_NAME", "n")
+	t.Setenv("WSRS_WEBSOCKET_READ_TIMEOUT", WebSocketPingInterval.String())
+	_, err := Load(nil)
+	if err == nil || !strings.Contains(err.Error(), "timeouts.websocket_read") {
+		t.Errorf("read timeout equal to the ping interval: err = %v, want it rejected", err)
+	}
+
+	t.Setenv("WSRS_WEBSOCKET_READ_TIMEOUT", "")
+	t.Setenv("WSRS_WEBHOOK_ALLOWED_CIDRS", "10.0.0.0/8, 192.168.1.300/24")
+	_, err = Load(nil)
+	if err == nil || !strings.Contains(err.Error(), "192.168.1.300/24") {
+		t.Errorf("invalid webhook cidr: err = %v, want it named", err)
+	}
+}
+
+func TestConnStringQuotesValuesAndUsesUTC(t *testing.T) {
+	db := Database{Host: "db.internal", Port: 5433, User: "app", Password: `p'a ss\`, Name: "wsrs"}
+
+	parsed, err := pgconn.ParseConfig(db.ConnString())
+	if err != nil {
+		t.Fatalf("ParseConfig: %v", err)
+	}
+	if parsed.Password != db.Password || parsed.Host != db.Host || parsed.Port != 5433 {
+		t.Errorf("parsed %s:%d password %q, want %s:%d password %q", parsed.Host, parsed.Port, parsed.Password, db.Host, db.Port, db.Password)
+	}
+	if tz := parsed.RuntimeParams["timezone"]; tz != "UTC" {
+		t.Errorf("session time zone = %q, want UTC", tz)
+	}
+}
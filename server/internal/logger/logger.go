@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/JeanGrijp/ask-me-anything/internal/config"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -183,10 +184,11 @@ var jsonEncoder = zapcore.NewJSONEncoder(zapcore.EncoderConfig{
 	EncodeCaller: zapcore.ShortCallerEncoder,
 })
 
-func NewZapLogger() *ZapLogger {
-	// choose level from env
+// NewZapLogger logs to stdout and, when cfg.File is set, to a rotated JSON file
+func NewZapLogger(cfg config.Log) *ZapLogger {
+	// choose level from config
 	level := zap.InfoLevel
-	switch strings.ToLower(os.Getenv("LOG_LEVEL")) {
+	switch strings.ToLower(cfg.Level) {
 	case "debug":
 		level = zap.DebugLevel
 	case "warn":
@@ -195,19 +197,23 @@ func NewZapLogger() *ZapLogger {
 		level = zap.ErrorLevel
 	}
 
-	// rotating file writer
-	fileWriter := zapcore.AddSync(&lumberjack.Logger{
-		Filename:   "./internal/logger/logs/api.log",
-		MaxSize:    10, // MB
-		MaxBackups: 5,
-		MaxAge:     30, // days
-		Compress:   true,
-	})
-
-	core := zapcore.NewTee(
+	cores := []zapcore.Core{
 		zapcore.NewCore(consoleEncoder, zapcore.AddSync(os.Stdout), level),
-		zapcore.NewCore(jsonEncoder, fileWriter, level),
-	)
+	}
+
+	// rotating file writer
+	if cfg.File != "" {
+		fileWriter := zapcore.AddSync(&lumberjack.Logger{
+			Filename:   cfg.File,
+			MaxSize:    10, // MB
+			MaxBackups: 5,
+			MaxAge:     30, // days
+			Compress:   true,
+		})
+		cores = append(cores, zapcore.NewCore(jsonEncoder, fileWriter, level))
+	}
+
+	core := zapcore.NewTee(cores...)
 
 	zapLogger := zap.New(core,
 		zap.AddCaller(),
@@ -255,4 +261,5 @@ func (z *ZapLogger) Fatal(ctx context.Context, msg string, fields ...any) {
 
 /* ---------- default instance exported ------------------------------------ */
 
-var Default Logger = NewZapLogger()
+// Default starts with the default configuration; main replaces it once the configuration is loaded
+var Default Logger = NewZapLogger(config.Default().Log)
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS room_templates (
+    "id"                uuid            PRIMARY KEY     NOT NULL    DEFAULT gen_random_uuid(),
+    "owner_session_id"  uuid                            NOT NULL,
+    "name"              VARCHAR(100)                    NOT NULL,
+    "theme"             VARCHAR(255)                    NOT NULL,
+    "questions"         TEXT[]                          NOT NULL    DEFAULT '{}',
+    "created_at"        TIMESTAMP                       NOT NULL    DEFAULT NOW(),
+
+    FOREIGN KEY (owner_session_id) REFERENCES user_sessions(id) ON DELETE CASCADE,
+    UNIQUE (owner_session_id, name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_room_templates_owner ON room_templates (owner_session_id);
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS room_templates;
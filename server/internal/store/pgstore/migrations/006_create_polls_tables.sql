@@ -0,0 +1,49 @@
+CREATE TABLE IF NOT EXISTS polls (
+    "id"            uuid            PRIMARY KEY     NOT NULL    DEFAULT gen_random_uuid(),
+    "room_id"       uuid                            NOT NULL,
+    "question"      VARCHAR(255)                    NOT NULL,
+    "status"        VARCHAR(20)                     NOT NULL    DEFAULT 'draft', -- 'draft', 'open', 'closed'
+    "created_at"    TIMESTAMP                       NOT NULL    DEFAULT NOW(),
+    "opened_at"     TIMESTAMP,
+    "closed_at"     TIMESTAMP,
+
+    FOREIGN KEY (room_id) REFERENCES rooms(id) ON DELETE CASCADE,
+    CHECK (status IN ('draft', 'open', 'closed'))
+);
+
+CREATE TABLE IF NOT EXISTS poll_options (
+    "id"            uuid            PRIMARY KEY     NOT NULL    DEFAULT gen_random_uuid(),
+    "poll_id"       uuid                            NOT NULL,
+    "position"      INTEGER                         NOT NULL,
+    "label"         VARCHAR(100)                    NOT NULL,
+
+    FOREIGN KEY (poll_id) REFERENCES polls(id) ON DELETE CASCADE,
+    UNIQUE (poll_id, position)
+);
+
+CREATE TABLE IF NOT EXISTS poll_votes (
+    "id"            uuid            PRIMARY KEY     NOT NULL    DEFAULT gen_random_uuid(),
+    "poll_id"       uuid                            NOT NULL,
+    "option_id"     uuid                            NOT NULL,
+    "session_id"    uuid                            NOT NULL,
+    "created_at"    TIMESTAMP                       NOT NULL    DEFAULT NOW(),
+
+    FOREIGN KEY (poll_id) REFERENCES polls(id) ON DELETE CASCADE,
+    FOREIGN KEY (option_id) REFERENCES poll_options(id) ON DELETE CASCADE,
+    FOREIGN KEY (session_id) REFERENCES user_sessions(id) ON DELETE CASCADE,
+
+    -- Cada sessão vota apenas uma vez por enquete
+    UNIQUE (poll_id, session_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_polls_room ON polls (room_id);
+
+CREATE INDEX IF NOT EXISTS idx_poll_votes_option ON poll_votes (option_id);
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS poll_votes;
+
+DROP TABLE IF EXISTS poll_options;
+
+DROP TABLE IF EXISTS polls;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS room_spotlights (
+    "room_id"           uuid            PRIMARY KEY     NOT NULL,
+    "message_id"        uuid                            NOT NULL,
+    "spotlighted_at"    TIMESTAMP                       NOT NULL    DEFAULT NOW(),
+
+    FOREIGN KEY (room_id) REFERENCES rooms(id) ON DELETE CASCADE,
+    FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE
+);
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS room_spotlights;
@@ -0,0 +1,28 @@
+ALTER TABLE messages
+    ADD COLUMN IF NOT EXISTS "created_at"           TIMESTAMP       NOT NULL    DEFAULT NOW(),
+    ADD COLUMN IF NOT EXISTS "answered_at"          TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS "author_session_id"    uuid            REFERENCES user_sessions(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_messages_room_created ON messages (room_id, created_at);
+
+CREATE TABLE IF NOT EXISTS room_subscriber_samples (
+    "id"                BIGSERIAL       PRIMARY KEY     NOT NULL,
+    "room_id"           uuid                            NOT NULL,
+    "subscriber_count"  INTEGER                         NOT NULL,
+    "recorded_at"       TIMESTAMP                       NOT NULL    DEFAULT NOW(),
+
+    FOREIGN KEY (room_id) REFERENCES rooms(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_room_subscriber_samples_room ON room_subscriber_samples (room_id, recorded_at);
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS room_subscriber_samples;
+
+DROP INDEX IF EXISTS idx_messages_room_created;
+
+ALTER TABLE messages
+    DROP COLUMN IF EXISTS "author_session_id",
+    DROP COLUMN IF EXISTS "answered_at",
+    DROP COLUMN IF EXISTS "created_at";
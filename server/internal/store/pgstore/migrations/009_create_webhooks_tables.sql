@@ -0,0 +1,45 @@
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    "id"                uuid            PRIMARY KEY     NOT NULL    DEFAULT gen_random_uuid(),
+    "room_id"           uuid                            NOT NULL,
+    "url"               TEXT                            NOT NULL,
+    "secret"            TEXT                            NOT NULL,
+    "event_kinds"       TEXT[]                          NOT NULL    DEFAULT '{}',
+    "active"            BOOLEAN                         NOT NULL    DEFAULT TRUE,
+    "created_at"        TIMESTAMP                       NOT NULL    DEFAULT NOW(),
+
+    FOREIGN KEY (room_id) REFERENCES rooms(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_subscriptions_room ON webhook_subscriptions (room_id);
+
+-- As entregas guardam uma cópia da URL e do segredo para sobreviver à remoção
+-- da assinatura (por exemplo, o evento room_deleted)
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    "id"                BIGSERIAL       PRIMARY KEY     NOT NULL,
+    "subscription_id"   uuid,
+    "room_id"           uuid                            NOT NULL,
+    "url"               TEXT                            NOT NULL,
+    "secret"            TEXT                            NOT NULL,
+    "event_kind"        TEXT                            NOT NULL,
+    "payload"           JSONB                           NOT NULL,
+    "status"            TEXT                            NOT NULL    DEFAULT 'pending',
+    "attempts"          INTEGER                         NOT NULL    DEFAULT 0,
+    "next_attempt_at"   TIMESTAMP                       NOT NULL    DEFAULT NOW(),
+    "last_attempt_at"   TIMESTAMP,
+    "last_status_code"  INTEGER,
+    "last_error"        TEXT,
+    "created_at"        TIMESTAMP                       NOT NULL    DEFAULT NOW(),
+    "delivered_at"      TIMESTAMP,
+
+    FOREIGN KEY (subscription_id) REFERENCES webhook_subscriptions(id) ON DELETE SET NULL,
+    CHECK (status IN ('pending', 'delivered', 'dead'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_pending ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription ON webhook_deliveries (subscription_id, id);
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS webhook_deliveries;
+
+DROP TABLE IF EXISTS webhook_subscriptions;
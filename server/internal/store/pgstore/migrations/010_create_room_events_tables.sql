@@ -0,0 +1,21 @@
+-- Sem chave estrangeira para rooms: o evento room_deleted é registrado depois da remoção da sala
+CREATE TABLE IF NOT EXISTS room_event_sequences (
+    "room_id"           uuid            PRIMARY KEY     NOT NULL,
+    "last_seq"          BIGINT                          NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS room_events (
+    "room_id"           uuid                            NOT NULL,
+    "seq"               BIGINT                          NOT NULL,
+    "kind"              TEXT                            NOT NULL,
+    "payload"           JSONB                           NOT NULL,
+    "created_at"        TIMESTAMP                       NOT NULL    DEFAULT NOW(),
+
+    PRIMARY KEY (room_id, seq)
+);
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS room_events;
+
+DROP TABLE IF EXISTS room_event_sequences;
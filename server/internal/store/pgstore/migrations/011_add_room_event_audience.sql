@@ -0,0 +1,8 @@
+-- Público do evento dentro da sala; NULL entrega a todos
+ALTER TABLE room_events
+    ADD COLUMN IF NOT EXISTS "audience"     JSONB;
+
+---- create above / drop below ----
+
+ALTER TABLE room_events
+    DROP COLUMN IF EXISTS "audience";
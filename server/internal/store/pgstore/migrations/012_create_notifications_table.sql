@@ -0,0 +1,21 @@
+-- Caixa de notificações pessoais de cada sessão de usuário
+CREATE TABLE IF NOT EXISTS notifications (
+    "id"                uuid            PRIMARY KEY     NOT NULL    DEFAULT gen_random_uuid(),
+    "session_id"        uuid                            NOT NULL,
+    "kind"              TEXT                            NOT NULL,
+    "room_id"           uuid                            NOT NULL,
+    "message_id"        uuid                            NOT NULL,
+    "read_at"           TIMESTAMP,
+    "created_at"        TIMESTAMP                       NOT NULL    DEFAULT NOW(),
+
+    FOREIGN KEY (session_id) REFERENCES user_sessions(id) ON DELETE CASCADE,
+    FOREIGN KEY (room_id) REFERENCES rooms(id) ON DELETE CASCADE,
+    FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE,
+    UNIQUE (session_id, kind, message_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_session ON notifications (session_id, created_at DESC);
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS notifications;
@@ -0,0 +1,16 @@
+-- Sessões que acompanham uma pergunta para receber suas atualizações
+CREATE TABLE IF NOT EXISTS message_followers (
+    "message_id"        uuid                            NOT NULL,
+    "session_id"        uuid                            NOT NULL,
+    "created_at"        TIMESTAMP                       NOT NULL    DEFAULT NOW(),
+
+    PRIMARY KEY (message_id, session_id),
+    FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE,
+    FOREIGN KEY (session_id) REFERENCES user_sessions(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_followers_session ON message_followers (session_id);
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS message_followers;
@@ -0,0 +1,14 @@
+-- Salas públicas aparecem na listagem e no lobby; salas não listadas só são acessadas pelo link
+ALTER TABLE rooms
+    ADD COLUMN IF NOT EXISTS "visibility"   TEXT            NOT NULL    DEFAULT 'public',
+    ADD CONSTRAINT rooms_visibility_check CHECK (visibility IN ('public', 'unlisted'));
+
+CREATE INDEX IF NOT EXISTS idx_rooms_public ON rooms (id) WHERE visibility = 'public';
+
+---- create above / drop below ----
+
+DROP INDEX IF EXISTS idx_rooms_public;
+
+ALTER TABLE rooms
+    DROP CONSTRAINT IF EXISTS rooms_visibility_check,
+    DROP COLUMN IF EXISTS "visibility";
@@ -0,0 +1,17 @@
+-- Cada instância grava a própria contagem por intervalo; a sala tem a soma das instâncias.
+-- instance vazio marca amostras já agregadas, como as gravadas antes desta migração.
+ALTER TABLE room_subscriber_samples
+    ADD COLUMN IF NOT EXISTS "instance"     TEXT            NOT NULL    DEFAULT '';
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_room_subscriber_samples_slot ON room_subscriber_samples (room_id, instance, recorded_at);
+
+CREATE INDEX IF NOT EXISTS idx_room_subscriber_samples_raw ON room_subscriber_samples (recorded_at) WHERE instance <> '';
+
+---- create above / drop below ----
+
+DROP INDEX IF EXISTS idx_room_subscriber_samples_raw;
+
+DROP INDEX IF EXISTS idx_room_subscriber_samples_slot;
+
+ALTER TABLE room_subscriber_samples
+    DROP COLUMN IF EXISTS "instance";
@@ -12,16 +12,61 @@ import (
 )
 
 type Message struct {
-	ID            uuid.UUID `db:"id" json:"id"`
-	RoomID        uuid.UUID `db:"room_id" json:"room_id"`
-	Message       string    `db:"message" json:"message"`
-	ReactionCount int64     `db:"reaction_count" json:"reaction_count"`
-	Answered      bool      `db:"answered" json:"answered"`
+	ID              uuid.UUID        `db:"id" json:"id"`
+	RoomID          uuid.UUID        `db:"room_id" json:"room_id"`
+	Message         string           `db:"message" json:"message"`
+	ReactionCount   int64            `db:"reaction_count" json:"reaction_count"`
+	Answered        bool             `db:"answered" json:"answered"`
+	CreatedAt       pgtype.Timestamp `db:"created_at" json:"created_at"`
+	AnsweredAt      pgtype.Timestamp `db:"answered_at" json:"answered_at"`
+	AuthorSessionID pgtype.UUID      `db:"author_session_id" json:"author_session_id"`
+}
+
+type MessageFollower struct {
+	MessageID uuid.UUID        `db:"message_id" json:"message_id"`
+	SessionID uuid.UUID        `db:"session_id" json:"session_id"`
+	CreatedAt pgtype.Timestamp `db:"created_at" json:"created_at"`
+}
+
+type Notification struct {
+	ID        uuid.UUID        `db:"id" json:"id"`
+	SessionID uuid.UUID        `db:"session_id" json:"session_id"`
+	Kind      string           `db:"kind" json:"kind"`
+	RoomID    uuid.UUID        `db:"room_id" json:"room_id"`
+	MessageID uuid.UUID        `db:"message_id" json:"message_id"`
+	ReadAt    pgtype.Timestamp `db:"read_at" json:"read_at"`
+	CreatedAt pgtype.Timestamp `db:"created_at" json:"created_at"`
+}
+
+type Poll struct {
+	ID        uuid.UUID        `db:"id" json:"id"`
+	RoomID    uuid.UUID        `db:"room_id" json:"room_id"`
+	Question  string           `db:"question" json:"question"`
+	Status    string           `db:"status" json:"status"`
+	CreatedAt pgtype.Timestamp `db:"created_at" json:"created_at"`
+	OpenedAt  pgtype.Timestamp `db:"opened_at" json:"opened_at"`
+	ClosedAt  pgtype.Timestamp `db:"closed_at" json:"closed_at"`
+}
+
+type PollOption struct {
+	ID       uuid.UUID `db:"id" json:"id"`
+	PollID   uuid.UUID `db:"poll_id" json:"poll_id"`
+	Position int32     `db:"position" json:"position"`
+	Label    string    `db:"label" json:"label"`
+}
+
+type PollVote struct {
+	ID        uuid.UUID        `db:"id" json:"id"`
+	PollID    uuid.UUID        `db:"poll_id" json:"poll_id"`
+	OptionID  uuid.UUID        `db:"option_id" json:"option_id"`
+	SessionID uuid.UUID        `db:"session_id" json:"session_id"`
+	CreatedAt pgtype.Timestamp `db:"created_at" json:"created_at"`
 }
 
 type Room struct {
-	ID    uuid.UUID `db:"id" json:"id"`
-	Theme string    `db:"theme" json:"theme"`
+	ID         uuid.UUID `db:"id" json:"id"`
+	Theme      string    `db:"theme" json:"theme"`
+	Visibility string    `db:"visibility" json:"visibility"`
 }
 
 type RoomCreator struct {
@@ -30,6 +75,43 @@ type RoomCreator struct {
 	CreatedAt        pgtype.Timestamp `db:"created_at" json:"created_at"`
 }
 
+type RoomEvent struct {
+	RoomID    uuid.UUID        `db:"room_id" json:"room_id"`
+	Seq       int64            `db:"seq" json:"seq"`
+	Kind      string           `db:"kind" json:"kind"`
+	Payload   []byte           `db:"payload" json:"payload"`
+	CreatedAt pgtype.Timestamp `db:"created_at" json:"created_at"`
+	Audience  []byte           `db:"audience" json:"audience"`
+}
+
+type RoomEventSequence struct {
+	RoomID  uuid.UUID `db:"room_id" json:"room_id"`
+	LastSeq int64     `db:"last_seq" json:"last_seq"`
+}
+
+type RoomSpotlight struct {
+	RoomID        uuid.UUID        `db:"room_id" json:"room_id"`
+	MessageID     uuid.UUID        `db:"message_id" json:"message_id"`
+	SpotlightedAt pgtype.Timestamp `db:"spotlighted_at" json:"spotlighted_at"`
+}
+
+type RoomSubscriberSample struct {
+	ID              int64            `db:"id" json:"id"`
+	RoomID          uuid.UUID        `db:"room_id" json:"room_id"`
+	SubscriberCount int32            `db:"subscriber_count" json:"subscriber_count"`
+	RecordedAt      pgtype.Timestamp `db:"recorded_at" json:"recorded_at"`
+	Instance        string           `db:"instance" json:"instance"`
+}
+
+type RoomTemplate struct {
+	ID             uuid.UUID        `db:"id" json:"id"`
+	OwnerSessionID uuid.UUID        `db:"owner_session_id" json:"owner_session_id"`
+	Name           string           `db:"name" json:"name"`
+	Theme          string           `db:"theme" json:"theme"`
+	Questions      []string         `db:"questions" json:"questions"`
+	CreatedAt      pgtype.Timestamp `db:"created_at" json:"created_at"`
+}
+
 type UserReaction struct {
 	ID           uuid.UUID        `db:"id" json:"id"`
 	SessionID    uuid.UUID        `db:"session_id" json:"session_id"`
@@ -50,3 +132,31 @@ type UserSession struct {
 	UserAgent    pgtype.Text      `db:"user_agent" json:"user_agent"`
 	IpAddress    *netip.Addr      `db:"ip_address" json:"ip_address"`
 }
+
+type WebhookDelivery struct {
+	ID             int64            `db:"id" json:"id"`
+	SubscriptionID pgtype.UUID      `db:"subscription_id" json:"subscription_id"`
+	RoomID         uuid.UUID        `db:"room_id" json:"room_id"`
+	Url            string           `db:"url" json:"url"`
+	Secret         string           `db:"secret" json:"secret"`
+	EventKind      string           `db:"event_kind" json:"event_kind"`
+	Payload        []byte           `db:"payload" json:"payload"`
+	Status         string           `db:"status" json:"status"`
+	Attempts       int32            `db:"attempts" json:"attempts"`
+	NextAttemptAt  pgtype.Timestamp `db:"next_attempt_at" json:"next_attempt_at"`
+	LastAttemptAt  pgtype.Timestamp `db:"last_attempt_at" json:"last_attempt_at"`
+	LastStatusCode pgtype.Int4      `db:"last_status_code" json:"last_status_code"`
+	LastError      pgtype.Text      `db:"last_error" json:"last_error"`
+	CreatedAt      pgtype.Timestamp `db:"created_at" json:"created_at"`
+	DeliveredAt    pgtype.Timestamp `db:"delivered_at" json:"delivered_at"`
+}
+
+type WebhookSubscription struct {
+	ID         uuid.UUID        `db:"id" json:"id"`
+	RoomID     uuid.UUID        `db:"room_id" json:"room_id"`
+	Url        string           `db:"url" json:"url"`
+	Secret     string           `db:"secret" json:"secret"`
+	EventKinds []string         `db:"event_kinds" json:"event_kinds"`
+	Active     bool             `db:"active" json:"active"`
+	CreatedAt  pgtype.Timestamp `db:"created_at" json:"created_at"`
+}
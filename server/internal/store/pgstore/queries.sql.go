@@ -150,21 +150,33 @@ func (q *Queries) DeleteUserSession(ctx context.Context, sessionToken string) er
 }
 
 const getMessage = `-- name: GetMessage :one
-SELECT "id", "room_id", "message", "reaction_count", "answered"
+SELECT "id", "room_id", "message", "reaction_count", "answered", "created_at", "answered_at"
 FROM messages
 WHERE
     id = $1
 `
 
-func (q *Queries) GetMessage(ctx context.Context, id uuid.UUID) (Message, error) {
+type GetMessageRow struct {
+	ID            uuid.UUID        `db:"id" json:"id"`
+	RoomID        uuid.UUID        `db:"room_id" json:"room_id"`
+	Message       string           `db:"message" json:"message"`
+	ReactionCount int64            `db:"reaction_count" json:"reaction_count"`
+	Answered      bool             `db:"answered" json:"answered"`
+	CreatedAt     pgtype.Timestamp `db:"created_at" json:"created_at"`
+	AnsweredAt    pgtype.Timestamp `db:"answered_at" json:"answered_at"`
+}
+
+func (q *Queries) GetMessage(ctx context.Context, id uuid.UUID) (GetMessageRow, error) {
 	row := q.db.QueryRow(ctx, getMessage, id)
-	var i Message
+	var i GetMessageRow
 	err := row.Scan(
 		&i.ID,
 		&i.RoomID,
 		&i.Message,
 		&i.ReactionCount,
 		&i.Answered,
+		&i.CreatedAt,
+		&i.AnsweredAt,
 	)
 	return i, err
 }
@@ -204,13 +216,13 @@ func (q *Queries) GetMessageReactions(ctx context.Context, messageID uuid.UUID)
 }
 
 const getRoom = `-- name: GetRoom :one
-SELECT "id", "theme" FROM rooms WHERE id = $1
+SELECT "id", "theme", "visibility" FROM rooms WHERE id = $1
 `
 
 func (q *Queries) GetRoom(ctx context.Context, id uuid.UUID) (Room, error) {
 	row := q.db.QueryRow(ctx, getRoom, id)
 	var i Room
-	err := row.Scan(&i.ID, &i.Theme)
+	err := row.Scan(&i.ID, &i.Theme, &i.Visibility)
 	return i, err
 }
 
@@ -237,27 +249,55 @@ func (q *Queries) GetRoomCreator(ctx context.Context, roomID uuid.UUID) (GetRoom
 }
 
 const getRoomMessages = `-- name: GetRoomMessages :many
-SELECT "id", "room_id", "message", "reaction_count", "answered"
-FROM messages
+SELECT
+    m.id,
+    m.room_id,
+    m.message,
+    m.reaction_count,
+    m.answered,
+    m.created_at,
+    m.answered_at,
+    (
+        SELECT COUNT(*)
+        FROM message_followers f
+        WHERE
+            f.message_id = m.id
+    ) AS follower_count
+FROM messages m
 WHERE
-    room_id = $1
+    m.room_id = $1
+ORDER BY m.created_at, m.id
 `
 
-func (q *Queries) GetRoomMessages(ctx context.Context, roomID uuid.UUID) ([]Message, error) {
+type GetRoomMessagesRow struct {
+	ID            uuid.UUID        `db:"id" json:"id"`
+	RoomID        uuid.UUID        `db:"room_id" json:"room_id"`
+	Message       string           `db:"message" json:"message"`
+	ReactionCount int64            `db:"reaction_count" json:"reaction_count"`
+	Answered      bool             `db:"answered" json:"answered"`
+	CreatedAt     pgtype.Timestamp `db:"created_at" json:"created_at"`
+	AnsweredAt    pgtype.Timestamp `db:"answered_at" json:"answered_at"`
+	FollowerCount int64            `db:"follower_count" json:"follower_count"`
+}
+
+func (q *Queries) GetRoomMessages(ctx context.Context, roomID uuid.UUID) ([]GetRoomMessagesRow, error) {
 	rows, err := q.db.Query(ctx, getRoomMessages, roomID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Message
+	var items []GetRoomMessagesRow
 	for rows.Next() {
-		var i Message
+		var i GetRoomMessagesRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.RoomID,
 			&i.Message,
 			&i.ReactionCount,
 			&i.Answered,
+			&i.CreatedAt,
+			&i.AnsweredAt,
+			&i.FollowerCount,
 		); err != nil {
 			return nil, err
 		}
@@ -276,10 +316,26 @@ SELECT
     m.message,
     m.reaction_count,
     m.answered,
+    m.created_at,
+    m.answered_at,
     CASE
         WHEN ur.id IS NOT NULL THEN true
         ELSE false
-    END as user_reacted
+    END as user_reacted,
+    (
+        SELECT COUNT(*)
+        FROM message_followers f
+        WHERE
+            f.message_id = m.id
+    ) AS follower_count,
+    EXISTS (
+        SELECT 1
+        FROM message_followers f
+            JOIN user_sessions us ON us.id = f.session_id
+        WHERE
+            f.message_id = m.id
+            AND us.session_token = $2
+    ) AS user_following
 FROM messages m
     LEFT JOIN user_reactions ur ON (
         m.id = ur.message_id
@@ -303,12 +359,16 @@ type GetRoomMessagesWithUserReactionsParams struct {
 }
 
 type GetRoomMessagesWithUserReactionsRow struct {
-	ID            uuid.UUID `db:"id" json:"id"`
-	RoomID        uuid.UUID `db:"room_id" json:"room_id"`
-	Message       string    `db:"message" json:"message"`
-	ReactionCount int64     `db:"reaction_count" json:"reaction_count"`
-	Answered      bool      `db:"answered" json:"answered"`
-	UserReacted   bool      `db:"user_reacted" json:"user_reacted"`
+	ID            uuid.UUID        `db:"id" json:"id"`
+	RoomID        uuid.UUID        `db:"room_id" json:"room_id"`
+	Message       string           `db:"message" json:"message"`
+	ReactionCount int64            `db:"reaction_count" json:"reaction_count"`
+	Answered      bool             `db:"answered" json:"answered"`
+	CreatedAt     pgtype.Timestamp `db:"created_at" json:"created_at"`
+	AnsweredAt    pgtype.Timestamp `db:"answered_at" json:"answered_at"`
+	UserReacted   bool             `db:"user_reacted" json:"user_reacted"`
+	FollowerCount int64            `db:"follower_count" json:"follower_count"`
+	UserFollowing bool             `db:"user_following" json:"user_following"`
 }
 
 func (q *Queries) GetRoomMessagesWithUserReactions(ctx context.Context, arg GetRoomMessagesWithUserReactionsParams) ([]GetRoomMessagesWithUserReactionsRow, error) {
@@ -326,7 +386,11 @@ func (q *Queries) GetRoomMessagesWithUserReactions(ctx context.Context, arg GetR
 			&i.Message,
 			&i.ReactionCount,
 			&i.Answered,
+			&i.CreatedAt,
+			&i.AnsweredAt,
 			&i.UserReacted,
+			&i.FollowerCount,
+			&i.UserFollowing,
 		); err != nil {
 			return nil, err
 		}
@@ -339,7 +403,7 @@ func (q *Queries) GetRoomMessagesWithUserReactions(ctx context.Context, arg GetR
 }
 
 const getRooms = `-- name: GetRooms :many
-SELECT "id", "theme" FROM rooms
+SELECT "id", "theme", "visibility" FROM rooms WHERE visibility = 'public'
 `
 
 func (q *Queries) GetRooms(ctx context.Context) ([]Room, error) {
@@ -351,7 +415,56 @@ func (q *Queries) GetRooms(ctx context.Context) ([]Room, error) {
 	var items []Room
 	for rows.Next() {
 		var i Room
-		if err := rows.Scan(&i.ID, &i.Theme); err != nil {
+		if err := rows.Scan(&i.ID, &i.Theme, &i.Visibility); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRoomsActivity = `-- name: GetRoomsActivity :many
+SELECT
+    r.id,
+    COUNT(m.id) AS message_count,
+    COUNT(m.id) FILTER (
+        WHERE
+            m.answered
+    ) AS answered_count,
+    COALESCE(SUM(m.reaction_count), 0)::bigint AS reaction_count
+FROM rooms r
+    LEFT JOIN messages m ON m.room_id = r.id
+WHERE
+    r.id = ANY($1::uuid[])
+    AND r.visibility = 'public'
+GROUP BY r.id
+`
+
+type GetRoomsActivityRow struct {
+	ID            uuid.UUID `db:"id" json:"id"`
+	MessageCount  int64     `db:"message_count" json:"message_count"`
+	AnsweredCount int64     `db:"answered_count" json:"answered_count"`
+	ReactionCount int64     `db:"reaction_count" json:"reaction_count"`
+}
+
+func (q *Queries) GetRoomsActivity(ctx context.Context, roomIds []uuid.UUID) ([]GetRoomsActivityRow, error) {
+	rows, err := q.db.Query(ctx, getRoomsActivity, roomIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetRoomsActivityRow
+	for rows.Next() {
+		var i GetRoomsActivityRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.MessageCount,
+			&i.AnsweredCount,
+			&i.ReactionCount,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -460,35 +573,130 @@ func (q *Queries) GetUserSession(ctx context.Context, sessionToken string) (GetU
 	return i, err
 }
 
+const insertImportedMessages = `-- name: InsertImportedMessages :many
+INSERT INTO
+    messages (
+        "room_id",
+        "message",
+        "reaction_count",
+        "answered",
+        "created_at"
+    )
+SELECT $1::uuid, m.message, m.reaction_count, m.answered, NOW() + m.position * INTERVAL '1 microsecond'
+FROM unnest(
+        $2::text[], $3::bigint[], $4::boolean[]
+    ) WITH ORDINALITY AS m (message, reaction_count, answered, position) RETURNING "id",
+    "message",
+    "reaction_count",
+    "answered"
+`
+
+type InsertImportedMessagesParams struct {
+	RoomID         uuid.UUID `db:"room_id" json:"room_id"`
+	Messages       []string  `db:"messages" json:"messages"`
+	ReactionCounts []int64   `db:"reaction_counts" json:"reaction_counts"`
+	Answered       []bool    `db:"answered" json:"answered"`
+}
+
+type InsertImportedMessagesRow struct {
+	ID            uuid.UUID `db:"id" json:"id"`
+	Message       string    `db:"message" json:"message"`
+	ReactionCount int64     `db:"reaction_count" json:"reaction_count"`
+	Answered      bool      `db:"answered" json:"answered"`
+}
+
+// Message Import Operations
+// Cada linha recebe um created_at distinto, na ordem do array, para que a listagem mantenha a ordem de origem
+func (q *Queries) InsertImportedMessages(ctx context.Context, arg InsertImportedMessagesParams) ([]InsertImportedMessagesRow, error) {
+	rows, err := q.db.Query(ctx, insertImportedMessages,
+		arg.RoomID,
+		arg.Messages,
+		arg.ReactionCounts,
+		arg.Answered,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []InsertImportedMessagesRow
+	for rows.Next() {
+		var i InsertImportedMessagesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Message,
+			&i.ReactionCount,
+			&i.Answered,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const insertMessage = `-- name: InsertMessage :one
 INSERT INTO
-    messages ("room_id", "message")
-VALUES ($1, $2) RETURNING "id"
+    messages (
+        "room_id",
+        "message",
+        "author_session_id"
+    )
+VALUES ($1, $2, $3) RETURNING "id"
 `
 
 type InsertMessageParams struct {
-	RoomID  uuid.UUID `db:"room_id" json:"room_id"`
-	Message string    `db:"message" json:"message"`
+	RoomID          uuid.UUID   `db:"room_id" json:"room_id"`
+	Message         string      `db:"message" json:"message"`
+	AuthorSessionID pgtype.UUID `db:"author_session_id" json:"author_session_id"`
 }
 
 func (q *Queries) InsertMessage(ctx context.Context, arg InsertMessageParams) (uuid.UUID, error) {
-	row := q.db.QueryRow(ctx, insertMessage, arg.RoomID, arg.Message)
+	row := q.db.QueryRow(ctx, insertMessage, arg.RoomID, arg.Message, arg.AuthorSessionID)
 	var id uuid.UUID
 	err := row.Scan(&id)
 	return id, err
 }
 
 const insertRoom = `-- name: InsertRoom :one
-INSERT INTO rooms ("theme") VALUES ($1) RETURNING "id"
+INSERT INTO rooms ("theme", "visibility") VALUES ($1, $2) RETURNING "id"
 `
 
-func (q *Queries) InsertRoom(ctx context.Context, theme string) (uuid.UUID, error) {
-	row := q.db.QueryRow(ctx, insertRoom, theme)
+type InsertRoomParams struct {
+	Theme      string `db:"theme" json:"theme"`
+	Visibility string `db:"visibility" json:"visibility"`
+}
+
+func (q *Queries) InsertRoom(ctx context.Context, arg InsertRoomParams) (uuid.UUID, error) {
+	row := q.db.QueryRow(ctx, insertRoom, arg.Theme, arg.Visibility)
 	var id uuid.UUID
 	err := row.Scan(&id)
 	return id, err
 }
 
+const insertRoomMessages = `-- name: InsertRoomMessages :execrows
+INSERT INTO
+    messages ("room_id", "message", "created_at")
+SELECT $1::uuid, m.message, NOW() + m.position * INTERVAL '1 microsecond'
+FROM unnest($2::text[]) WITH ORDINALITY AS m (message, position)
+`
+
+type InsertRoomMessagesParams struct {
+	RoomID   uuid.UUID `db:"room_id" json:"room_id"`
+	Messages []string  `db:"messages" json:"messages"`
+}
+
+// Cada linha recebe um created_at distinto, na ordem do array, para que a listagem mantenha a ordem de origem
+func (q *Queries) InsertRoomMessages(ctx context.Context, arg InsertRoomMessagesParams) (int64, error) {
+	result, err := q.db.Exec(ctx, insertRoomMessages, arg.RoomID, arg.Messages)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const isRoomCreator = `-- name: IsRoomCreator :one
 SELECT EXISTS (
         SELECT 1
@@ -514,7 +722,12 @@ func (q *Queries) IsRoomCreator(ctx context.Context, arg IsRoomCreatorParams) (b
 }
 
 const markMessageAsAnswered = `-- name: MarkMessageAsAnswered :exec
-UPDATE messages SET answered = true WHERE id = $1
+UPDATE messages
+SET
+    answered = true,
+    answered_at = COALESCE(answered_at, NOW())
+WHERE
+    id = $1
 `
 
 func (q *Queries) MarkMessageAsAnswered(ctx context.Context, id uuid.UUID) error {
@@ -591,6 +804,49 @@ func (q *Queries) SetRoomCreator(ctx context.Context, arg SetRoomCreatorParams)
 	return err
 }
 
+const updateRoom = `-- name: UpdateRoom :one
+WITH "previous" AS (
+    SELECT "id", "visibility"
+    FROM rooms
+    WHERE
+        id = $1
+    FOR UPDATE
+)
+UPDATE rooms r
+SET
+    theme = COALESCE($2, r.theme),
+    visibility = COALESCE($3, r.visibility)
+FROM "previous"
+WHERE
+    r.id = previous.id
+RETURNING r.id, r.theme, r.visibility, previous.visibility AS previous_visibility
+`
+
+type UpdateRoomParams struct {
+	ID         uuid.UUID   `db:"id" json:"id"`
+	Theme      pgtype.Text `db:"theme" json:"theme"`
+	Visibility pgtype.Text `db:"visibility" json:"visibility"`
+}
+
+type UpdateRoomRow struct {
+	ID                 uuid.UUID `db:"id" json:"id"`
+	Theme              string    `db:"theme" json:"theme"`
+	Visibility         string    `db:"visibility" json:"visibility"`
+	PreviousVisibility string    `db:"previous_visibility" json:"previous_visibility"`
+}
+
+func (q *Queries) UpdateRoom(ctx context.Context, arg UpdateRoomParams) (UpdateRoomRow, error) {
+	row := q.db.QueryRow(ctx, updateRoom, arg.ID, arg.Theme, arg.Visibility)
+	var i UpdateRoomRow
+	err := row.Scan(
+		&i.ID,
+		&i.Theme,
+		&i.Visibility,
+		&i.PreviousVisibility,
+	)
+	return i, err
+}
+
 const updateSessionActivity = `-- name: UpdateSessionActivity :exec
 UPDATE user_sessions
 SET
@@ -0,0 +1,149 @@
+-- Room Analytics Operations
+-- name: GetRoomMessageStats :one
+SELECT
+    COUNT(*) AS total_questions,
+    COUNT(DISTINCT author_session_id) AS unique_askers,
+    COUNT(*) FILTER (
+        WHERE
+            answered
+    ) AS answered_questions,
+    COUNT(answered_at) AS timed_answers,
+    COALESCE(SUM(reaction_count), 0)::bigint AS total_reactions,
+    (
+        SELECT COUNT(*)
+        FROM user_reactions ur
+        WHERE
+            ur.room_id = $1
+    ) AS timed_reactions,
+    COALESCE(
+        percentile_cont(0.5) WITHIN GROUP (
+            ORDER BY EXTRACT(
+                    EPOCH
+                    FROM (answered_at - created_at)
+                )
+        ) FILTER (
+            WHERE
+                answered_at IS NOT NULL
+        ),
+        0
+    )::double precision AS median_seconds_to_answer
+FROM messages
+WHERE
+    room_id = $1;
+
+-- name: DownsampleRoomSubscriberSamples :execrows
+WITH
+    raw AS (
+        DELETE FROM room_subscriber_samples
+        WHERE
+            instance <> ''
+            AND recorded_at < @before::timestamp RETURNING room_id,
+            subscriber_count,
+            recorded_at
+    ),
+    slots AS (
+        SELECT room_id, recorded_at, SUM(subscriber_count) AS total
+        FROM raw
+        GROUP BY
+            room_id,
+            recorded_at
+    )
+INSERT INTO
+    room_subscriber_samples (
+        "room_id",
+        "instance",
+        "subscriber_count",
+        "recorded_at"
+    )
+SELECT room_id, '', MAX(total)::integer, date_trunc('hour', recorded_at)
+FROM slots
+GROUP BY
+    room_id,
+    date_trunc('hour', recorded_at) ON CONFLICT (room_id, instance, recorded_at) DO
+UPDATE
+SET
+    subscriber_count = GREATEST(
+        room_subscriber_samples.subscriber_count,
+        EXCLUDED.subscriber_count
+    );
+
+-- name: GetRoomPeakSubscribers :one
+SELECT COALESCE(MAX(total), 0)::integer AS peak_subscribers
+FROM (
+        SELECT SUM(subscriber_count) AS total
+        FROM room_subscriber_samples
+        WHERE
+            room_id = $1
+        GROUP BY
+            recorded_at
+    ) AS slots;
+
+-- name: GetRoomQuestionSeries :many
+SELECT date_bin(
+        @bucket_seconds::bigint * INTERVAL '1 second', created_at, TIMESTAMP '2000-01-01'
+    )::timestamp AS bucket, COUNT(*) AS total
+FROM messages
+WHERE
+    room_id = @room_id
+GROUP BY
+    bucket
+ORDER BY bucket;
+
+-- name: GetRoomAnswerSeries :many
+SELECT date_bin(
+        @bucket_seconds::bigint * INTERVAL '1 second', answered_at, TIMESTAMP '2000-01-01'
+    )::timestamp AS bucket, COUNT(*) AS total
+FROM messages
+WHERE
+    room_id = @room_id
+    AND answered_at IS NOT NULL
+GROUP BY
+    bucket
+ORDER BY bucket;
+
+-- name: GetRoomReactionSeries :many
+SELECT date_bin(
+        @bucket_seconds::bigint * INTERVAL '1 second', created_at, TIMESTAMP '2000-01-01'
+    )::timestamp AS bucket, COUNT(*) AS total
+FROM user_reactions
+WHERE
+    room_id = @room_id
+GROUP BY
+    bucket
+ORDER BY bucket;
+
+-- name: GetRoomSubscriberSeries :many
+SELECT date_bin(
+        @bucket_seconds::bigint * INTERVAL '1 second', recorded_at, TIMESTAMP '2000-01-01'
+    )::timestamp AS bucket, MAX(total)::bigint AS total
+FROM (
+        SELECT recorded_at, SUM(subscriber_count) AS total
+        FROM room_subscriber_samples
+        WHERE
+            room_id = @room_id
+        GROUP BY
+            recorded_at
+    ) AS slots
+GROUP BY
+    bucket
+ORDER BY bucket;
+
+-- name: InsertRoomSubscriberSample :exec
+INSERT INTO
+    room_subscriber_samples (
+        "room_id",
+        "instance",
+        "subscriber_count",
+        "recorded_at"
+    )
+VALUES (
+        @room_id, @instance, @subscriber_count, date_bin(
+            @slot_seconds::bigint * INTERVAL '1 second', NOW()::timestamp, TIMESTAMP '2000-01-01'
+        )
+    ) ON CONFLICT (room_id, instance, recorded_at) DO
+UPDATE
+SET
+    subscriber_count = GREATEST(
+        room_subscriber_samples.subscriber_count,
+        EXCLUDED.subscriber_count
+    );
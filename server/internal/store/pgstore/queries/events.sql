@@ -0,0 +1,57 @@
+-- Room Event Log Operations
+-- name: AppendRoomEvent :one
+WITH
+    next AS (
+        INSERT INTO
+            room_event_sequences ("room_id", "last_seq")
+        VALUES (@room_id, 1) ON CONFLICT (room_id) DO
+        UPDATE
+        SET
+            last_seq = room_event_sequences.last_seq + 1 RETURNING last_seq
+    )
+INSERT INTO
+    room_events (
+        "room_id",
+        "seq",
+        "kind",
+        "payload",
+        "audience"
+    )
+SELECT @room_id, next.last_seq, @kind, @payload, @audience
+FROM next RETURNING seq;
+
+-- name: GetRoomLastEventSeq :one
+SELECT last_seq FROM room_event_sequences WHERE room_id = $1;
+
+-- name: GetRoomEventsSince :many
+SELECT seq, kind, payload, audience, created_at
+FROM room_events
+WHERE
+    room_id = @room_id
+    AND seq > @since
+ORDER BY seq
+LIMIT @max_events;
+
+-- name: PruneRoomEvents :execrows
+DELETE FROM room_events WHERE room_id = $1 AND seq <= $2;
+
+-- name: DeleteRoomEventLog :exec
+WITH
+    deleted_events AS (
+        DELETE FROM room_events
+        WHERE
+            room_id = $1
+    )
+DELETE FROM room_event_sequences
+WHERE
+    room_id = $1;
+
+-- name: GetRoomEvent :one
+SELECT seq, kind, payload, audience
+FROM room_events
+WHERE
+    room_id = $1
+    AND seq = $2;
+
+-- name: NotifyRoomEvent :exec
+SELECT pg_notify(@channel::text, @payload::text);
@@ -0,0 +1,7 @@
+-- Room Export Operations
+-- name: GetRoomExport :many
+SELECT "id", "message", "reaction_count", "answered", "created_at", "answered_at"
+FROM messages
+WHERE
+    room_id = $1
+ORDER BY created_at, id;
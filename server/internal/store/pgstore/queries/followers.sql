@@ -0,0 +1,38 @@
+-- name: FollowMessage :exec
+INSERT INTO
+    message_followers ("message_id", "session_id")
+VALUES ($1, $2) ON CONFLICT (message_id, session_id) DO NOTHING;
+
+-- name: UnfollowMessage :execrows
+DELETE FROM message_followers
+WHERE
+    message_id = $1
+    AND session_id = $2;
+
+-- name: CountMessageFollowers :one
+SELECT COUNT(*) FROM message_followers WHERE message_id = $1;
+
+-- name: InsertFollowerNotifications :many
+WITH "followed" AS (
+    SELECT "id", "room_id", "author_session_id"
+    FROM messages
+    WHERE
+        id = @message_id
+)
+INSERT INTO
+    notifications (
+        "session_id",
+        "kind",
+        "room_id",
+        "message_id"
+    )
+SELECT f.session_id, @kind::text, m.room_id, m.id
+FROM message_followers f
+    JOIN "followed" m ON m.id = f.message_id
+WHERE
+    NOT (
+        @exclude_author::boolean
+        AND f.session_id IS NOT DISTINCT FROM m.author_session_id
+    )
+ON CONFLICT ("session_id", "kind", "message_id") DO NOTHING
+RETURNING "session_id";
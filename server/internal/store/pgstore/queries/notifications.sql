@@ -0,0 +1,74 @@
+-- name: InsertAnswerNotification :one
+WITH "answered" AS (
+    SELECT "id", "room_id", "author_session_id", "message"
+    FROM messages
+    WHERE
+        id = $1
+        AND author_session_id IS NOT NULL
+), "inserted" AS (
+    INSERT INTO
+        notifications (
+            "session_id",
+            "kind",
+            "room_id",
+            "message_id"
+        )
+    SELECT "author_session_id", $2, "room_id", "id"
+    FROM "answered"
+    ON CONFLICT ("session_id", "kind", "message_id") DO NOTHING
+    RETURNING "id", "session_id", "room_id", "message_id", "created_at"
+)
+SELECT
+    inserted.id,
+    inserted.session_id,
+    inserted.room_id,
+    inserted.message_id,
+    inserted.created_at,
+    answered.message
+FROM "inserted"
+    JOIN "answered" ON answered.id = inserted.message_id;
+
+-- name: GetSessionNotifications :many
+SELECT
+    n.id,
+    n.kind,
+    n.room_id,
+    n.message_id,
+    n.read_at,
+    n.created_at,
+    r.theme,
+    m.message
+FROM notifications n
+    JOIN rooms r ON r.id = n.room_id
+    JOIN messages m ON m.id = n.message_id
+WHERE
+    n.session_id = @session_id
+    AND (
+        NOT @unread_only::boolean
+        OR n.read_at IS NULL
+    )
+ORDER BY n.created_at DESC
+LIMIT @max_count;
+
+-- name: CountUnreadNotifications :one
+SELECT COUNT(*)
+FROM notifications
+WHERE
+    session_id = $1
+    AND read_at IS NULL;
+
+-- name: MarkNotificationRead :execrows
+UPDATE notifications
+SET
+    read_at = COALESCE(read_at, NOW())
+WHERE
+    id = $1
+    AND session_id = $2;
+
+-- name: MarkAllNotificationsRead :execrows
+UPDATE notifications
+SET
+    read_at = NOW()
+WHERE
+    session_id = $1
+    AND read_at IS NULL;
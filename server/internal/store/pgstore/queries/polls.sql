@@ -0,0 +1,98 @@
+-- Poll Operations
+-- name: InsertPoll :one
+INSERT INTO
+    polls ("room_id", "question")
+VALUES ($1, $2) RETURNING "id",
+    "room_id",
+    "question",
+    "status",
+    "created_at",
+    "opened_at",
+    "closed_at";
+
+-- name: InsertPollOptions :exec
+INSERT INTO
+    poll_options ("poll_id", "position", "label")
+SELECT @poll_id::uuid, o.position::integer, o.label
+FROM unnest(@labels::text[]) WITH ORDINALITY AS o (label, position);
+
+-- name: GetPoll :one
+SELECT "id", "room_id", "question", "status", "created_at", "opened_at", "closed_at"
+FROM polls
+WHERE
+    id = $1
+    AND room_id = $2;
+
+-- name: GetRoomPolls :many
+SELECT "id", "room_id", "question", "status", "created_at", "opened_at", "closed_at"
+FROM polls
+WHERE
+    room_id = $1
+ORDER BY created_at;
+
+-- name: OpenPoll :one
+UPDATE polls
+SET
+    status = 'open',
+    opened_at = NOW()
+WHERE
+    id = $1
+    AND room_id = $2
+    AND status = 'draft' RETURNING "id",
+    "room_id",
+    "question",
+    "status",
+    "created_at",
+    "opened_at",
+    "closed_at";
+
+-- name: ClosePoll :one
+UPDATE polls
+SET
+    status = 'closed',
+    closed_at = NOW()
+WHERE
+    id = $1
+    AND room_id = $2
+    AND status = 'open' RETURNING "id",
+    "room_id",
+    "question",
+    "status",
+    "created_at",
+    "opened_at",
+    "closed_at";
+
+-- name: GetPollResults :many
+SELECT o.id, o.position, o.label, COUNT(v.id) AS votes
+FROM poll_options o
+    LEFT JOIN poll_votes v ON v.option_id = o.id
+WHERE
+    o.poll_id = $1
+GROUP BY
+    o.id
+ORDER BY o.position;
+
+-- name: InsertPollVote :execrows
+INSERT INTO
+    poll_votes (
+        "poll_id",
+        "option_id",
+        "session_id"
+    )
+SELECT @poll_id::uuid, @option_id::uuid, @session_id::uuid
+WHERE
+    EXISTS (
+        SELECT 1
+        FROM polls
+        WHERE
+            id = @poll_id
+            AND status = 'open'
+        FOR UPDATE
+    ) ON CONFLICT (poll_id, session_id) DO NOTHING;
+
+-- name: GetSessionPollVote :one
+SELECT option_id
+FROM poll_votes
+WHERE
+    poll_id = $1
+    AND session_id = $2;
@@ -1,23 +1,70 @@
 -- name: GetRoom :one
-SELECT "id", "theme" FROM rooms WHERE id = $1;
+SELECT "id", "theme", "visibility" FROM rooms WHERE id = $1;
 
 -- name: GetRooms :many
-SELECT "id", "theme" FROM rooms;
+SELECT "id", "theme", "visibility" FROM rooms WHERE visibility = 'public';
 
 -- name: InsertRoom :one
-INSERT INTO rooms ("theme") VALUES ($1) RETURNING "id";
+INSERT INTO rooms ("theme", "visibility") VALUES ($1, $2) RETURNING "id";
+
+-- name: UpdateRoom :one
+WITH "previous" AS (
+    SELECT "id", "visibility"
+    FROM rooms
+    WHERE
+        id = @id
+    FOR UPDATE
+)
+UPDATE rooms r
+SET
+    theme = COALESCE(sqlc.narg(theme), r.theme),
+    visibility = COALESCE(sqlc.narg(visibility), r.visibility)
+FROM "previous"
+WHERE
+    r.id = previous.id
+RETURNING r.id, r.theme, r.visibility, previous.visibility AS previous_visibility;
+
+-- name: GetRoomsActivity :many
+SELECT
+    r.id,
+    COUNT(m.id) AS message_count,
+    COUNT(m.id) FILTER (
+        WHERE
+            m.answered
+    ) AS answered_count,
+    COALESCE(SUM(m.reaction_count), 0)::bigint AS reaction_count
+FROM rooms r
+    LEFT JOIN messages m ON m.room_id = r.id
+WHERE
+    r.id = ANY(@room_ids::uuid[])
+    AND r.visibility = 'public'
+GROUP BY r.id;
 
 -- name: GetMessage :one
-SELECT "id", "room_id", "message", "reaction_count", "answered"
+SELECT "id", "room_id", "message", "reaction_count", "answered", "created_at", "answered_at"
 FROM messages
 WHERE
     id = $1;
 
 -- name: GetRoomMessages :many
-SELECT "id", "room_id", "message", "reaction_count", "answered"
-FROM messages
+SELECT
+    m.id,
+    m.room_id,
+    m.message,
+    m.reaction_count,
+    m.answered,
+    m.created_at,
+    m.answered_at,
+    (
+        SELECT COUNT(*)
+        FROM message_followers f
+        WHERE
+            f.message_id = m.id
+    ) AS follower_count
+FROM messages m
 WHERE
-    room_id = $1;
+    m.room_id = $1
+ORDER BY m.created_at, m.id;
 
 -- name: GetRoomMessagesWithUserReactions :many
 SELECT
@@ -26,10 +73,26 @@ SELECT
     m.message,
     m.reaction_count,
     m.answered,
+    m.created_at,
+    m.answered_at,
     CASE
         WHEN ur.id IS NOT NULL THEN true
         ELSE false
-    END as user_reacted
+    END as user_reacted,
+    (
+        SELECT COUNT(*)
+        FROM message_followers f
+        WHERE
+            f.message_id = m.id
+    ) AS follower_count,
+    EXISTS (
+        SELECT 1
+        FROM message_followers f
+            JOIN user_sessions us ON us.id = f.session_id
+        WHERE
+            f.message_id = m.id
+            AND us.session_token = $2
+    ) AS user_following
 FROM messages m
     LEFT JOIN user_reactions ur ON (
         m.id = ur.message_id
@@ -48,8 +111,19 @@ ORDER BY m.id;
 
 -- name: InsertMessage :one
 INSERT INTO
-    messages ("room_id", "message")
-VALUES ($1, $2) RETURNING "id";
+    messages (
+        "room_id",
+        "message",
+        "author_session_id"
+    )
+VALUES ($1, $2, $3) RETURNING "id";
+
+-- name: InsertRoomMessages :execrows
+-- Cada linha recebe um created_at distinto, na ordem do array, para que a listagem mantenha a ordem de origem
+INSERT INTO
+    messages ("room_id", "message", "created_at")
+SELECT @room_id::uuid, m.message, NOW() + m.position * INTERVAL '1 microsecond'
+FROM unnest(@messages::text[]) WITH ORDINALITY AS m (message, position);
 
 -- name: ReactToMessage :one
 UPDATE messages
@@ -66,7 +140,12 @@ WHERE
     id = $1 RETURNING reaction_count;
 
 -- name: MarkMessageAsAnswered :exec
-UPDATE messages SET answered = true WHERE id = $1;
+UPDATE messages
+SET
+    answered = true,
+    answered_at = COALESCE(answered_at, NOW())
+WHERE
+    id = $1;
 
 -- User Session Operations
 -- name: CreateUserSession :one
@@ -215,4 +294,22 @@ WHERE
     AND EXISTS (
         SELECT 1
         FROM room_check
-    );
\ No newline at end of file
+    );
+-- Message Import Operations
+-- name: InsertImportedMessages :many
+-- Cada linha recebe um created_at distinto, na ordem do array, para que a listagem mantenha a ordem de origem
+INSERT INTO
+    messages (
+        "room_id",
+        "message",
+        "reaction_count",
+        "answered",
+        "created_at"
+    )
+SELECT @room_id::uuid, m.message, m.reaction_count, m.answered, NOW() + m.position * INTERVAL '1 microsecond'
+FROM unnest(
+        @messages::text[], @reaction_counts::bigint[], @answered::boolean[]
+    ) WITH ORDINALITY AS m (message, reaction_count, answered, position) RETURNING "id",
+    "message",
+    "reaction_count",
+    "answered";
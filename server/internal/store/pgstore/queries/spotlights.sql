@@ -0,0 +1,19 @@
+-- Room Spotlight Operations
+-- name: SetRoomSpotlight :exec
+INSERT INTO
+    room_spotlights ("room_id", "message_id")
+VALUES ($1, $2) ON CONFLICT (room_id) DO
+UPDATE
+SET
+    message_id = EXCLUDED.message_id,
+    spotlighted_at = NOW();
+
+-- name: ClearRoomSpotlight :execrows
+DELETE FROM room_spotlights WHERE room_id = $1;
+
+-- name: GetRoomSpotlight :one
+SELECT m.id, m.message, m.reaction_count, m.answered, rs.spotlighted_at
+FROM room_spotlights rs
+    JOIN messages m ON m.id = rs.message_id
+WHERE
+    rs.room_id = $1;
@@ -0,0 +1,32 @@
+-- Room Template Operations
+-- name: InsertRoomTemplate :one
+INSERT INTO
+    room_templates (
+        "owner_session_id",
+        "name",
+        "theme",
+        "questions"
+    )
+VALUES ($1, $2, $3, $4) RETURNING "id",
+    "owner_session_id",
+    "name",
+    "theme",
+    "questions",
+    "created_at";
+
+-- name: GetRoomTemplate :one
+SELECT "id", "owner_session_id", "name", "theme", "questions", "created_at"
+FROM room_templates
+WHERE
+    id = $1
+    AND owner_session_id = $2;
+
+-- name: GetSessionRoomTemplates :many
+SELECT "id", "owner_session_id", "name", "theme", "questions", "created_at"
+FROM room_templates
+WHERE
+    owner_session_id = $1
+ORDER BY created_at DESC;
+
+-- name: DeleteRoomTemplate :execrows
+DELETE FROM room_templates WHERE id = $1 AND owner_session_id = $2;
@@ -0,0 +1,119 @@
+-- Webhook Subscription Operations
+-- name: InsertWebhookSubscription :one
+INSERT INTO
+    webhook_subscriptions (
+        "room_id",
+        "url",
+        "secret",
+        "event_kinds"
+    )
+VALUES ($1, $2, $3, $4) RETURNING *;
+
+-- name: GetRoomWebhookSubscriptions :many
+SELECT *
+FROM webhook_subscriptions
+WHERE
+    room_id = $1
+ORDER BY created_at;
+
+-- name: GetWebhookSubscription :one
+SELECT * FROM webhook_subscriptions WHERE id = $1 AND room_id = $2;
+
+-- name: DeleteWebhookSubscription :execrows
+DELETE FROM webhook_subscriptions WHERE id = $1 AND room_id = $2;
+
+-- Webhook Delivery Operations
+-- name: EnqueueWebhookDeliveries :execrows
+INSERT INTO
+    webhook_deliveries (
+        "subscription_id",
+        "room_id",
+        "url",
+        "secret",
+        "event_kind",
+        "payload"
+    )
+SELECT ws.id, ws.room_id, ws.url, ws.secret, @event_kind::text, @payload::jsonb
+FROM webhook_subscriptions ws
+WHERE
+    ws.room_id = @room_id
+    AND ws.active
+    AND (
+        cardinality(ws.event_kinds) = 0
+        OR @event_kind::text = ANY (ws.event_kinds)
+    );
+
+-- name: ClaimWebhookDeliveries :many
+UPDATE webhook_deliveries
+SET
+    attempts = attempts + 1,
+    last_attempt_at = NOW(),
+    next_attempt_at = NOW() + @lease_seconds::int * INTERVAL '1 second'
+WHERE
+    id IN (
+        SELECT id
+        FROM webhook_deliveries
+        WHERE
+            status = 'pending'
+            AND next_attempt_at <= NOW()
+        ORDER BY next_attempt_at
+        LIMIT @batch_size FOR UPDATE SKIP LOCKED
+    ) RETURNING id,
+    url,
+    secret,
+    event_kind,
+    payload,
+    attempts;
+
+-- name: MarkWebhookDeliveryDelivered :exec
+UPDATE webhook_deliveries
+SET
+    status = 'delivered',
+    delivered_at = NOW(),
+    last_status_code = $2,
+    last_error = NULL
+WHERE
+    id = $1;
+
+-- name: MarkWebhookDeliveryFailed :exec
+UPDATE webhook_deliveries
+SET
+    status = CASE
+        WHEN @dead::boolean THEN 'dead'
+        ELSE 'pending'
+    END,
+    next_attempt_at = @next_attempt_at,
+    last_status_code = @last_status_code,
+    last_error = @last_error
+WHERE
+    id = @id;
+
+-- name: GetWebhookDeliveries :many
+SELECT
+    id,
+    event_kind,
+    payload,
+    status,
+    attempts,
+    next_attempt_at,
+    last_attempt_at,
+    last_status_code,
+    last_error,
+    created_at,
+    delivered_at
+FROM webhook_deliveries
+WHERE
+    subscription_id = $1
+ORDER BY id DESC
+LIMIT $2;
+
+-- name: RetryWebhookDelivery :execrows
+UPDATE webhook_deliveries
+SET
+    status = 'pending',
+    attempts = 0,
+    next_attempt_at = NOW()
+WHERE
+    id = $1
+    AND subscription_id = $2
+    AND status = 'dead';
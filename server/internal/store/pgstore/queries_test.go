@@ -0,0 +1,102 @@
+package pgstore
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// testQueries returns queries bound to a transaction rolled back at the end of the test. The
+// tests run against the migrated database in WSRS_TEST_DATABASE_URL and are skipped without it.
+func testQueries(t *testing.T) *Queries {
+	t.Helper()
+
+	url := os.Getenv("WSRS_TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("WSRS_TEST_DATABASE_URL not set")
+	}
+
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, url)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { conn.Close(ctx) })
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	t.Cleanup(func() { _ = tx.Rollback(ctx) })
+
+	return New(tx)
+}
+
+func testQuestions(n int) []string {
+	questions := make([]string, n)
+	for i := range questions {
+		questions[i] = fmt.Sprintf("question %03d", i)
+	}
+	return questions
+}
+
+// assertRoomOrder checks that the room lists exactly want, in order
+func assertRoomOrder(t *testing.T, q *Queries, roomID uuid.UUID, want []string) {
+	t.Helper()
+
+	rows, err := q.GetRoomMessages(context.Background(), roomID)
+	if err != nil {
+		t.Fatalf("GetRoomMessages: %v", err)
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("room has %d messages, want %d", len(rows), len(want))
+	}
+	for i, row := range rows {
+		if row.Message != want[i] {
+			t.Fatalf("message %d is %q, want %q", i, row.Message, want[i])
+		}
+	}
+}
+
+func TestInsertRoomMessagesKeepsOrder(t *testing.T) {
+	q := testQueries(t)
+	ctx := context.Background()
+
+	roomID, err := q.InsertRoom(ctx, InsertRoomParams{Theme: "order", Visibility: "public"})
+	if err != nil {
+		t.Fatalf("InsertRoom: %v", err)
+	}
+
+	questions := testQuestions(200)
+	if _, err := q.InsertRoomMessages(ctx, InsertRoomMessagesParams{RoomID: roomID, Messages: questions}); err != nil {
+		t.Fatalf("InsertRoomMessages: %v", err)
+	}
+
+	assertRoomOrder(t, q, roomID, questions)
+}
+
+func TestInsertImportedMessagesKeepsOrder(t *testing.T) {
+	q := testQueries(t)
+	ctx := context.Background()
+
+	roomID, err := q.InsertRoom(ctx, InsertRoomParams{Theme: "order", Visibility: "public"})
+	if err != nil {
+		t.Fatalf("InsertRoom: %v", err)
+	}
+
+	questions := testQuestions(200)
+	if _, err := q.InsertImportedMessages(ctx, InsertImportedMessagesParams{
+		RoomID:         roomID,
+		Messages:       questions,
+		ReactionCounts: make([]int64, len(questions)),
+		Answered:       make([]bool, len(questions)),
+	}); err != nil {
+		t.Fatalf("InsertImportedMessages: %v", err)
+	}
+
+	assertRoomOrder(t, q, roomID, questions)
+}
@@ -0,0 +1,39 @@
+// This file is maintained by hand. sqlc only generates queries that buffer every
+// row into a slice, so queries that may return very large result sets get a
+// streaming variant here that reuses the generated SQL and row types.
+
+package pgstore
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// StreamRoomExport runs GetRoomExport and calls fn for each row as it is read,
+// without keeping the whole result set in memory. Iteration stops at the first
+// error returned by fn.
+func (q *Queries) StreamRoomExport(ctx context.Context, roomID uuid.UUID, fn func(GetRoomExportRow) error) error {
+	rows, err := q.db.Query(ctx, getRoomExport, roomID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var i GetRoomExportRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Message,
+			&i.ReactionCount,
+			&i.Answered,
+			&i.CreatedAt,
+			&i.AnsweredAt,
+		); err != nil {
+			return err
+		}
+		if err := fn(i); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,138 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenTarget is returned for endpoints that resolve to an address of the server's own
+// network, such as loopback, private or link-local addresses, outside the allowed networks
+var ErrForbiddenTarget = errors.New("webhook url must resolve to a public address or an allowed network")
+
+// Tempo máximo da resolução de DNS feita ao validar uma URL
+const resolveTimeout = 2 * time.Second
+
+// Faixas especiais que não são cobertas pelos métodos de netip.Addr
+var forbiddenPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),      // "esta rede"
+	netip.MustParsePrefix("100.64.0.0/10"),  // CGNAT
+	netip.MustParsePrefix("192.0.0.0/24"),   // atribuições de protocolo da IETF
+	netip.MustParsePrefix("198.18.0.0/15"),  // testes de desempenho
+	netip.MustParsePrefix("240.0.0.0/4"),    // reservado, inclui broadcast
+	netip.MustParsePrefix("64:ff9b::/96"),   // NAT64, pode apontar para IPv4 interno
+	netip.MustParsePrefix("64:ff9b:1::/48"), // NAT64 local
+	netip.MustParsePrefix("2002::/16"),      // 6to4, embute um IPv4 qualquer
+}
+
+// PublicAddress reports whether webhooks may be delivered to addr
+func PublicAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	if !addr.IsValid() || addr.IsUnspecified() || addr.IsLoopback() || addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() {
+		return false
+	}
+
+	for _, prefix := range forbiddenPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// TargetPolicy decides which addresses webhooks may be delivered to: public addresses, and the
+// networks explicitly allowed, such as the network of internal tools or a local receiver
+type TargetPolicy struct {
+	allowed []netip.Prefix
+}
+
+func NewTargetPolicy(allowed []netip.Prefix) TargetPolicy {
+	return TargetPolicy{allowed: allowed}
+}
+
+// Allows reports whether webhooks may be delivered to addr
+func (p TargetPolicy) Allows(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range p.allowed {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return PublicAddress(addr)
+}
+
+// ValidateURL checks that rawURL is an absolute http(s) URL whose host resolves only to addresses
+// the policy allows. The dispatcher checks the address again when connecting, so a DNS answer
+// that changes after this check (DNS rebinding) is still refused.
+func (p TargetPolicy) ValidateURL(ctx context.Context, rawURL string) error {
+	target, err := url.Parse(rawURL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Hostname() == "" {
+		return errors.New("url must be an absolute http or https url")
+	}
+	if target.User != nil {
+		return errors.New("url must not contain credentials")
+	}
+
+	host := target.Hostname()
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if !p.Allows(addr) {
+			return ErrForbiddenTarget
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, resolveTimeout)
+	defer cancel()
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil || len(addrs) == 0 {
+		return fmt.Errorf("could not resolve webhook host %q", host)
+	}
+	for _, addr := range addrs {
+		if !p.Allows(addr) {
+			return ErrForbiddenTarget
+		}
+	}
+	return nil
+}
+
+// newHTTPClient returns the client used to send deliveries. Every connection is checked by
+// allow right before it is made, after DNS resolution, and redirects are not followed: a 3xx
+// counts as a failed delivery, so an endpoint cannot bounce the request to an internal address.
+func newHTTPClient(allow func(netip.Addr) bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !allow(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s", ErrForbiddenTarget, addrPort.Addr())
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// Um proxy do ambiente faria a conexão no lugar do dialer e escaparia da verificação
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   requestTimeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
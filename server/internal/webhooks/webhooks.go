@@ -0,0 +1,253 @@
+// Package webhooks delivers room events to external HTTP endpoints.
+//
+// Events are written to the webhook_deliveries table and sent by a Dispatcher
+// that polls the table, so pending deliveries survive restarts. Failed
+// deliveries are retried with exponential backoff until MaxAttempts, after
+// which they are kept in the "dead" state until retried manually.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/JeanGrijp/ask-me-anything/internal/logger"
+	"github.com/JeanGrijp/ask-me-anything/internal/store/pgstore"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	TimestampHeader = "X-Webhook-Timestamp"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusDead      = "dead"
+
+	// MaxAttempts is the number of attempts before a delivery is moved to the dead state
+	MaxAttempts = 8
+
+	pollInterval   = 2 * time.Second
+	batchSize      = 20
+	requestTimeout = 10 * time.Second
+	// Uma entrega reivindicada fica invisível por esse tempo; se o processo cair
+	// no meio do envio, ela volta para a fila sozinha
+	leaseDuration = time.Minute
+	baseBackoff   = 10 * time.Second
+	maxBackoff    = time.Hour
+	maxErrorSize  = 500
+)
+
+// Event is the JSON body sent to webhook endpoints
+type Event struct {
+	Kind       string    `json:"kind"`
+	RoomID     string    `json:"room_id"`
+	Value      any       `json:"value"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// Enqueue stores one delivery of the event for every active subscription of the room
+// interested in it. Passing a transaction-bound q ties the deliveries to that transaction.
+func Enqueue(ctx context.Context, q *pgstore.Queries, roomID uuid.UUID, kind string, value any) (int64, error) {
+	payload, err := json.Marshal(Event{
+		Kind:       kind,
+		RoomID:     roomID.String(),
+		Value:      value,
+		OccurredAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode webhook event: %w", err)
+	}
+
+	return q.EnqueueWebhookDeliveries(ctx, pgstore.EnqueueWebhookDeliveriesParams{
+		EventKind: kind,
+		Payload:   payload,
+		RoomID:    roomID,
+	})
+}
+
+// NewSecret generates the signing secret of a new subscription
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// Sign returns the signature sent in SignatureHeader: the hex HMAC-SHA256 of
+// "<timestamp>.<body>" keyed with the subscription secret, prefixed with "sha256=".
+// Receivers should recompute it and compare with hmac.Equal.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Backoff returns how long to wait before retrying a delivery that failed on the given attempt
+func Backoff(attempt int32) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	delay := baseBackoff
+	for i := int32(1); i < attempt && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+
+	return min(delay, maxBackoff)
+}
+
+// Dispatcher sends pending deliveries to their endpoints
+type Dispatcher struct {
+	q      *pgstore.Queries
+	policy TargetPolicy
+	client *http.Client
+}
+
+// NewDispatcher returns a dispatcher that only connects to the addresses policy allows
+func NewDispatcher(q *pgstore.Queries, policy TargetPolicy) *Dispatcher {
+	return &Dispatcher{
+		q:      q,
+		policy: policy,
+		client: newHTTPClient(policy.Allows),
+	}
+}
+
+// ValidateURL checks a subscription URL against the policy the dispatcher delivers with
+func (d *Dispatcher) ValidateURL(ctx context.Context, rawURL string) error {
+	return d.policy.ValidateURL(ctx, rawURL)
+}
+
+// Run polls the delivery queue until ctx is cancelled
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.dispatch(ctx)
+		}
+	}
+}
+
+// dispatch claims and sends batches of due deliveries until the queue has no more work
+func (d *Dispatcher) dispatch(ctx context.Context) {
+	for ctx.Err() == nil {
+		deliveries, err := d.q.ClaimWebhookDeliveries(ctx, pgstore.ClaimWebhookDeliveriesParams{
+			LeaseSeconds: int32(leaseDuration.Seconds()),
+			BatchSize:    batchSize,
+		})
+		if err != nil {
+			logger.Default.Error(ctx, "failed to claim webhook deliveries", "error", err)
+			return
+		}
+
+		var wg sync.WaitGroup
+		for _, delivery := range deliveries {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				d.deliver(ctx, delivery)
+			}()
+		}
+		wg.Wait()
+
+		if len(deliveries) < batchSize {
+			return
+		}
+	}
+}
+
+// deliver sends one delivery and records the outcome
+func (d *Dispatcher) deliver(ctx context.Context, delivery pgstore.ClaimWebhookDeliveriesRow) {
+	statusCode, err := d.send(ctx, delivery)
+
+	var status pgtype.Int4
+	if statusCode > 0 {
+		status = pgtype.Int4{Int32: int32(statusCode), Valid: true}
+	}
+
+	if err == nil {
+		err = d.q.MarkWebhookDeliveryDelivered(ctx, pgstore.MarkWebhookDeliveryDeliveredParams{
+			ID:             delivery.ID,
+			LastStatusCode: status,
+		})
+		if err != nil {
+			logger.Default.Error(ctx, "failed to mark webhook delivery as delivered", "delivery_id", delivery.ID, "error", err)
+		}
+		return
+	}
+
+	dead := delivery.Attempts >= MaxAttempts
+	message := err.Error()
+	if len(message) > maxErrorSize {
+		message = message[:maxErrorSize]
+	}
+
+	if dead {
+		logger.Default.Warn(ctx, "webhook delivery moved to dead letter", "delivery_id", delivery.ID, "event_kind", delivery.EventKind, "attempts", delivery.Attempts, "error", message)
+	} else {
+		logger.Default.Debug(ctx, "webhook delivery failed", "delivery_id", delivery.ID, "event_kind", delivery.EventKind, "attempts", delivery.Attempts, "error", message)
+	}
+
+	err = d.q.MarkWebhookDeliveryFailed(ctx, pgstore.MarkWebhookDeliveryFailedParams{
+		Dead:           dead,
+		NextAttemptAt:  pgtype.Timestamp{Time: time.Now().Add(Backoff(delivery.Attempts)), Valid: true},
+		LastStatusCode: status,
+		LastError:      pgtype.Text{String: message, Valid: true},
+		ID:             delivery.ID,
+	})
+	if err != nil {
+		logger.Default.Error(ctx, "failed to mark webhook delivery as failed", "delivery_id", delivery.ID, "error", err)
+	}
+}
+
+// send performs the signed POST; any non-2xx response is an error
+func (d *Dispatcher) send(ctx context.Context, delivery pgstore.ClaimWebhookDeliveriesRow) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ask-me-anything-webhooks/1.0")
+	req.Header.Set(EventHeader, delivery.EventKind)
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(delivery.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// Descarta o corpo para reaproveitar a conexão
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded with status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}